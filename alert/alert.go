@@ -0,0 +1,273 @@
+// Package alert watches exchange rates and notifies webhooks when they cross thresholds
+package alert
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Conditions an alert Rule can watch for
+const (
+	Above  = "above"  // the rate rises above Threshold
+	Below  = "below"  // the rate falls below Threshold
+	Change = "change" // the rate moves by at least Threshold percent from the previous fixing
+)
+
+// maxHistory is the number of firings kept
+const maxHistory = 1000
+
+// ErrNotFound is returned when an alert rule does not exist
+var ErrNotFound = errors.New("alert not found")
+
+// Rule describes an exchange rate condition and the webhook to notify when it is met
+type Rule struct {
+	ID        string
+	Store     string
+	From      string
+	To        string
+	Condition string
+	Threshold float64
+	Webhook   string
+	Secret    string `json:",omitempty"` // key used to sign webhook deliveries
+	Owner     string `json:",omitempty"` // identifies the client managing the rule, such as its API key
+	LastFired string `json:",omitempty"` // date of the fixing the rule last fired for
+	Created   time.Time
+}
+
+// Firing records a rule being triggered and the delivery of its notification
+type Firing struct {
+	RuleID    string
+	Date      string
+	Rate      float64
+	Previous  float64
+	Fired     time.Time
+	Delivered bool
+	Attempts  int
+	Error     string `json:",omitempty"`
+}
+
+// Manager stores alert rules and their firing history and evaluates the rules
+type Manager struct {
+	Retries int           // delivery attempts made after the first one fails
+	Backoff time.Duration // wait before the first retry, doubled for every later one
+
+	mu         sync.Mutex
+	evalMu     sync.Mutex     // held while rules are evaluated
+	deliveries sync.WaitGroup // webhook deliveries in progress
+	path       string
+	lookup     func(name string) (exchangerates.Store, bool)
+	client     *http.Client
+	rules      map[string]*Rule
+	history    []Firing
+	now        func() time.Time
+}
+
+type state struct {
+	Rules   []*Rule
+	History []Firing
+}
+
+// New returns a Manager persisting its rules and history to the file at path, loading them
+// if it already exists. lookup resolves the store names used by rules
+func New(path string, lookup func(name string) (exchangerates.Store, bool)) (*Manager, error) {
+	m := &Manager{
+		Retries: 3,
+		Backoff: time.Second,
+		path:    path,
+		lookup:  lookup,
+		client:  &http.Client{Timeout: 10 * time.Second},
+		rules:   make(map[string]*Rule),
+		now:     time.Now,
+	}
+
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return m, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var st state
+	err = json.Unmarshal(data, &st)
+	if err != nil {
+		return nil, err
+	}
+	for _, r := range st.Rules {
+		m.rules[r.ID] = r
+	}
+	m.history = st.History
+	return m, nil
+}
+
+// List returns the rules of owner ordered by creation time
+func (m *Manager) List(owner string) []Rule {
+	rules := []Rule{}
+	for _, r := range m.all() {
+		if r.Owner == owner {
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// all returns the rules of every owner ordered by creation time
+func (m *Manager) all() []Rule {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	rules := make([]Rule, 0, len(m.rules))
+	for _, r := range m.rules {
+		rules = append(rules, *r)
+	}
+	sort.Slice(rules, func(i, j int) bool {
+		return rules[i].Created.Before(rules[j].Created)
+	})
+	return rules
+}
+
+// Get returns the rule of owner with the given id
+func (m *Manager) Get(owner, id string) (Rule, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.rules[id]
+	if !ok || r.Owner != owner {
+		return Rule{}, ErrNotFound
+	}
+	return *r, nil
+}
+
+// Create validates and stores a new rule of r.Owner, assigning it an id
+func (m *Manager) Create(r Rule) (Rule, error) {
+	err := m.validate(r)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	id := make([]byte, 8)
+	_, err = rand.Read(id)
+	if err != nil {
+		return Rule{}, err
+	}
+	r.ID = hex.EncodeToString(id)
+	r.Created = m.now().UTC()
+	r.LastFired = ""
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.rules[r.ID] = &r
+	return r, m.save()
+}
+
+// Update replaces the rule of owner with the given id. The secret of the rule is kept when r has none,
+// as it is not sent to clients
+func (m *Manager) Update(owner, id string, r Rule) (Rule, error) {
+	err := m.validate(r)
+	if err != nil {
+		return Rule{}, err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.rules[id]
+	if !ok || old.Owner != owner {
+		return Rule{}, ErrNotFound
+	}
+	if r.Secret == "" {
+		r.Secret = old.Secret
+	}
+	r.ID = id
+	r.Owner = owner
+	r.Created = old.Created
+	r.LastFired = ""
+	m.rules[id] = &r
+	return r, m.save()
+}
+
+// Delete removes the rule of owner with the given id
+func (m *Manager) Delete(owner, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.rules[id]; !ok || r.Owner != owner {
+		return ErrNotFound
+	}
+	delete(m.rules, id)
+	return m.save()
+}
+
+// History returns the firings of the rule of owner with the given id, oldest first
+func (m *Manager) History(owner, id string) ([]Firing, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if r, ok := m.rules[id]; !ok || r.Owner != owner {
+		return nil, ErrNotFound
+	}
+	firings := []Firing{}
+	for _, f := range m.history {
+		if f.RuleID == id {
+			firings = append(firings, f)
+		}
+	}
+	return firings, nil
+}
+
+func (m *Manager) validate(r Rule) error {
+	if _, ok := m.lookup(r.Store); !ok {
+		return errors.New(r.Store + " is not a valid store")
+	}
+	if r.From == "" || r.To == "" {
+		return errors.New("from and to currencies are required")
+	}
+	switch r.Condition {
+	case Above, Below:
+	case Change:
+		if r.Threshold <= 0 {
+			return errors.New("threshold of a change condition should be a positive percentage")
+		}
+	default:
+		return errors.New("condition should be one of above, below or change")
+	}
+	u, err := url.Parse(r.Webhook)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return errors.New("webhook should be an http or https URL")
+	}
+	return nil
+}
+
+// save writes the rules and history to the file. The caller must hold m.mu
+func (m *Manager) save() error {
+	if m.path == "" {
+		return nil
+	}
+
+	st := state{History: m.history}
+	for _, r := range m.rules {
+		st.Rules = append(st.Rules, r)
+	}
+	data, err := json.MarshalIndent(&st, "", "\t")
+	if err != nil {
+		return err
+	}
+
+	tmp := m.path + ".tmp"
+	err = ioutil.WriteFile(tmp, data, 0600)
+	if err != nil {
+		return err
+	}
+	return os.Rename(tmp, m.path)
+}
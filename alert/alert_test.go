@@ -0,0 +1,218 @@
+package alert
+
+import (
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+func newTestManager(t *testing.T, path string) *Manager {
+	s := mock.New()
+	s.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		switch date {
+		case "2017-03-03":
+			return 1.07, nil
+		case "2017-03-02":
+			return 1.05, nil
+		}
+		return 0, errors.New("date not found")
+	}
+
+	m, err := New(path, func(name string) (exchangerates.Store, bool) {
+		return s, name == "mock"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Backoff = time.Millisecond
+	m.now = func() time.Time {
+		return time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC)
+	}
+	return m
+}
+
+func TestEvaluate(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		attempts int
+		received []payload
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+
+		attempts++
+		if attempts == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+
+		body, _ := ioutil.ReadAll(req.Body)
+		if want, got := Sign("s3cret", body), req.Header.Get(SignatureHeader); want != got {
+			t.Errorf("expected signature=%q, got %q", want, got)
+		}
+		var p payload
+		json.Unmarshal(body, &p)
+		received = append(received, p)
+	}))
+	defer receiver.Close()
+
+	dir, err := ioutil.TempDir("", "alert")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "alerts.json")
+
+	m := newTestManager(t, path)
+	above, err := m.Create(Rule{Store: "mock", From: "EUR", To: "USD", Condition: Above, Threshold: 1.06, Webhook: receiver.URL, Secret: "s3cret", Owner: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Create(Rule{Store: "mock", From: "EUR", To: "USD", Condition: Below, Threshold: 1, Webhook: receiver.URL, Owner: "bob"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = m.Create(Rule{Store: "xyz", From: "EUR", To: "USD", Condition: Above, Threshold: 1, Webhook: receiver.URL})
+	if err == nil {
+		t.Fatal("expected error for invalid store")
+	}
+
+	if fired := m.EvaluateStore("other"); len(fired) != 0 {
+		t.Fatalf("expected no firing for the rules of another store, got %+v", fired)
+	}
+	fired := m.EvaluateStore("mock")
+	if len(fired) != 1 || fired[0].RuleID != above.ID || fired[0].Date != "2017-03-03" {
+		t.Fatalf("expected rule %s to fire for 2017-03-03, got %+v", above.ID, fired)
+	}
+	m.Wait()
+	history, err := m.History("alice", above.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || !history[0].Delivered || history[0].Attempts != 2 {
+		t.Fatalf("expected delivery on second attempt, got %+v", history)
+	}
+	if len(received) != 1 || received[0].Rule.Secret != "" || received[0].Rule.Owner != "" || received[0].Firing.Rate != 1.07 {
+		t.Fatalf("unexpected webhook payloads %+v", received)
+	}
+
+	if fired := m.Evaluate(); len(fired) != 0 {
+		t.Fatalf("expected no firing for an already notified fixing, got %+v", fired)
+	}
+
+	// rules and history should survive a restart
+	m = newTestManager(t, path)
+	if want, got := 1, len(m.List("alice")); want != got {
+		t.Fatalf("expected %d rules of alice after reload, got %d", want, got)
+	}
+	history, err = m.History("alice", above.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(history) != 1 || !history[0].Delivered {
+		t.Fatalf("unexpected history after reload %+v", history)
+	}
+	if fired := m.Evaluate(); len(fired) != 0 {
+		t.Fatalf("expected no firing after reload, got %+v", fired)
+	}
+}
+
+func TestOwner(t *testing.T) {
+	m := newTestManager(t, "")
+	r, err := m.Create(Rule{Store: "mock", From: "EUR", To: "USD", Condition: Above, Threshold: 1.06, Webhook: "http://example.com/hook", Secret: "s3cret", Owner: "alice"})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := m.Get("bob", r.ID); err != ErrNotFound {
+		t.Errorf("expected the rule of alice to be hidden from bob, got %v", err)
+	}
+	if rules := m.List("bob"); len(rules) != 0 {
+		t.Errorf("expected bob to have no rules, got %+v", rules)
+	}
+	if _, err := m.Update("bob", r.ID, r); err != ErrNotFound {
+		t.Errorf("expected bob not to update the rule of alice, got %v", err)
+	}
+	if err := m.Delete("bob", r.ID); err != ErrNotFound {
+		t.Errorf("expected bob not to delete the rule of alice, got %v", err)
+	}
+
+	// rules are sent to clients without their secret, which an update without one keeps
+	r.Secret, r.Threshold = "", 1.08
+	updated, err := m.Update("alice", r.ID, r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Secret != "s3cret" || updated.Owner != "alice" || updated.Threshold != 1.08 {
+		t.Errorf("expected the secret and owner to be kept, got %+v", updated)
+	}
+	if err := m.Delete("alice", r.ID); err != nil {
+		t.Errorf("expected alice to delete the rule, got %v", err)
+	}
+}
+
+func TestEvaluateConcurrently(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		received int
+	)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		mu.Lock()
+		defer mu.Unlock()
+		received++
+	}))
+	defer receiver.Close()
+
+	m := newTestManager(t, "")
+	_, err := m.Create(Rule{Store: "mock", From: "EUR", To: "USD", Condition: Above, Threshold: 1.06, Webhook: receiver.URL})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// stores refreshed at the same time evaluate the rules concurrently
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			m.Evaluate()
+		}()
+	}
+	wg.Wait()
+	m.Wait()
+	if received != 1 {
+		t.Fatalf("expected a single delivery, got %d", received)
+	}
+}
+
+func TestTriggered(t *testing.T) {
+	var tests = []struct {
+		Rule     Rule
+		Rate     float64
+		Prev     float64
+		Expected bool
+	}{
+		{Rule: Rule{Condition: Above, Threshold: 1.06}, Rate: 1.07, Prev: 1.05, Expected: true},
+		{Rule: Rule{Condition: Above, Threshold: 1.06}, Rate: 1.08, Prev: 1.07, Expected: false},
+		{Rule: Rule{Condition: Below, Threshold: 1.06}, Rate: 1.05, Prev: 1.07, Expected: true},
+		{Rule: Rule{Condition: Change, Threshold: 1}, Rate: 1.05, Prev: 1.07, Expected: true},
+		{Rule: Rule{Condition: Change, Threshold: 5}, Rate: 1.05, Prev: 1.07, Expected: false},
+	}
+
+	for i, tt := range tests {
+		if got := triggered(tt.Rule, tt.Rate, tt.Prev); got != tt.Expected {
+			t.Errorf("#%d failed: expected %v, got %v", i, tt.Expected, got)
+		}
+	}
+}
@@ -0,0 +1,125 @@
+package alert
+
+import (
+	"errors"
+	"math"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// lookback is the number of days searched for the latest fixings
+const lookback = 10
+
+// Evaluate checks every rule against the latest fixings of its store and notifies the webhooks
+// of the rules that are triggered in the background. A rule fires at most once for a given fixing date
+func (m *Manager) Evaluate() []Firing {
+	return m.evaluate(func(Rule) bool { return true })
+}
+
+// EvaluateStore is like Evaluate but only checks the rules watching the named store, such as one that
+// has just been refreshed
+func (m *Manager) EvaluateStore(name string) []Firing {
+	return m.evaluate(func(r Rule) bool { return r.Store == name })
+}
+
+// evaluate checks the rules selected by match. Evaluations are serialized so that a rule is never found
+// triggered for the same fixing twice, and the firings are recorded before their webhooks are notified
+// in the background, their history being updated once delivered. Wait waits for those deliveries
+func (m *Manager) evaluate(match func(Rule) bool) []Firing {
+	m.evalMu.Lock()
+	defer m.evalMu.Unlock()
+
+	var fired []Firing
+	for _, r := range m.all() {
+		if !match(r) {
+			continue
+		}
+		store, ok := m.lookup(r.Store)
+		if !ok {
+			continue
+		}
+
+		date, rate, prev, err := latestRates(store, r.From, r.To, m.now())
+		if err != nil || date == r.LastFired || !triggered(r, rate, prev) {
+			continue
+		}
+
+		f := Firing{RuleID: r.ID, Date: date, Rate: rate, Previous: prev, Fired: m.now().UTC()}
+		m.mu.Lock()
+		current, ok := m.rules[r.ID]
+		if ok {
+			current.LastFired = date
+			m.history = append(m.history, f)
+			if len(m.history) > maxHistory {
+				m.history = m.history[len(m.history)-maxHistory:]
+			}
+			m.save()
+		}
+		m.mu.Unlock()
+		if !ok {
+			continue
+		}
+
+		m.deliveries.Add(1)
+		go func(r Rule, f Firing) {
+			defer m.deliveries.Done()
+			m.deliver(r, &f)
+			m.recordDelivery(f)
+		}(r, f)
+		fired = append(fired, f)
+	}
+	return fired
+}
+
+// Wait waits for the webhook deliveries of the firings evaluated so far to succeed or give up
+func (m *Manager) Wait() {
+	m.deliveries.Wait()
+}
+
+// recordDelivery updates the firing of the history with the outcome of its delivery
+func (m *Manager) recordDelivery(f Firing) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := len(m.history) - 1; i >= 0; i-- {
+		if h := &m.history[i]; h.RuleID == f.RuleID && h.Date == f.Date {
+			h.Delivered, h.Attempts, h.Error = f.Delivered, f.Attempts, f.Error
+			m.save()
+			return
+		}
+	}
+}
+
+func triggered(r Rule, rate, prev float64) bool {
+	switch r.Condition {
+	case Above:
+		return prev <= r.Threshold && rate > r.Threshold
+	case Below:
+		return prev >= r.Threshold && rate < r.Threshold
+	case Change:
+		return math.Abs(rate/prev-1)*100 >= r.Threshold
+	}
+	return false
+}
+
+// latestRates returns the most recent fixing on or before now and the one preceding it
+func latestRates(s exchangerates.Store, from, to string, now time.Time) (date string, rate, prev float64, err error) {
+	day := now.UTC()
+	found := false
+	for i := 0; i < 2*lookback; i++ {
+		d := day.AddDate(0, 0, -i).Format("2006-01-02")
+		r, lookupErr := s.GetExchangeRate(from, to, d)
+		if lookupErr != nil {
+			if !found && i >= lookback {
+				break
+			}
+			continue
+		}
+		if !found {
+			date, rate, found = d, r, true
+			continue
+		}
+		return date, rate, r, nil
+	}
+	return "", 0, 0, errors.New("no recent rates for " + from + "/" + to)
+}
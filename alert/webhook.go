@@ -0,0 +1,75 @@
+package alert
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+)
+
+// SignatureHeader is the header carrying the HMAC-SHA256 signature of a webhook delivery body
+const SignatureHeader = "X-Exchangerates-Signature"
+
+type payload struct {
+	Rule   Rule
+	Firing Firing
+}
+
+// Sign returns the signature of body sent in SignatureHeader for deliveries made with secret
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// deliver posts the firing to the rule's webhook, retrying with exponential backoff on failure
+func (m *Manager) deliver(r Rule, f *Firing) {
+	secret := r.Secret
+	r.Secret, r.Owner = "", ""
+	body, err := json.Marshal(&payload{Rule: r, Firing: *f})
+	if err != nil {
+		f.Error = err.Error()
+		return
+	}
+
+	backoff := m.Backoff
+	for f.Attempts = 1; ; f.Attempts++ {
+		err = m.post(r.Webhook, secret, body)
+		if err == nil {
+			f.Delivered = true
+			f.Error = ""
+			return
+		}
+		f.Error = err.Error()
+		if f.Attempts > m.Retries {
+			return
+		}
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func (m *Manager) post(url, secret string, body []byte) error {
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if secret != "" {
+		req.Header.Set(SignatureHeader, Sign(secret, body))
+	}
+
+	resp, err := m.client.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+	}
+	return nil
+}
@@ -0,0 +1,143 @@
+// Package analytics provides statistics over exchange rate series
+package analytics
+
+import (
+	"errors"
+	"math"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/series"
+)
+
+// TradingDays is the number of observations per year used to annualise daily statistics
+const TradingDays = 252
+
+// Drawdown describes the largest fall of a series from a peak to a following trough
+type Drawdown struct {
+	Peak   exchangerates.DateRate
+	Trough exchangerates.DateRate
+	Value  float64 // relative fall from the peak, between 0 and 1
+}
+
+// SimpleReturns returns the relative change of every rate from the previous one, dated at the later rate
+func SimpleReturns(rates []exchangerates.DateRate) []exchangerates.DateRate {
+	var returns []exchangerates.DateRate
+	for i := 1; i < len(rates); i++ {
+		returns = append(returns, exchangerates.DateRate{Date: rates[i].Date, Rate: rates[i].Rate/rates[i-1].Rate - 1})
+	}
+	return returns
+}
+
+// LogReturns returns the natural logarithm of the ratio of every rate to the previous one, dated at the later rate
+func LogReturns(rates []exchangerates.DateRate) []exchangerates.DateRate {
+	var returns []exchangerates.DateRate
+	for i := 1; i < len(rates); i++ {
+		returns = append(returns, exchangerates.DateRate{Date: rates[i].Date, Rate: math.Log(rates[i].Rate / rates[i-1].Rate)})
+	}
+	return returns
+}
+
+// Volatility returns the sample standard deviation of a series of returns
+func Volatility(returns []exchangerates.DateRate) float64 {
+	if len(returns) < 2 {
+		return 0
+	}
+	m := mean(returns)
+	sum := 0.0
+	for _, r := range returns {
+		sum += (r.Rate - m) * (r.Rate - m)
+	}
+	return math.Sqrt(sum / float64(len(returns)-1))
+}
+
+// AnnualisedVolatility scales the volatility of returns observed periodsPerYear times a year to a yearly figure
+func AnnualisedVolatility(returns []exchangerates.DateRate, periodsPerYear int) float64 {
+	return Volatility(returns) * math.Sqrt(float64(periodsPerYear))
+}
+
+// RollingVolatility returns the volatility of every window of returns, dated at the last return of the window
+func RollingVolatility(returns []exchangerates.DateRate, window int) []exchangerates.DateRate {
+	var result []exchangerates.DateRate
+	for i := window; i <= len(returns); i++ {
+		result = append(result, exchangerates.DateRate{Date: returns[i-1].Date, Rate: Volatility(returns[i-window : i])})
+	}
+	return result
+}
+
+// MaxDrawdown returns the largest fall of the series from a peak to a following trough
+func MaxDrawdown(rates []exchangerates.DateRate) Drawdown {
+	var dd Drawdown
+	if len(rates) == 0 {
+		return dd
+	}
+	peak := rates[0]
+	dd.Peak, dd.Trough = peak, peak
+	for _, r := range rates {
+		if r.Rate > peak.Rate {
+			peak = r
+		}
+		if fall := 1 - r.Rate/peak.Rate; fall > dd.Value {
+			dd = Drawdown{Peak: peak, Trough: r, Value: fall}
+		}
+	}
+	return dd
+}
+
+// MinMax returns the lowest and highest rates of the series along with their dates
+func MinMax(rates []exchangerates.DateRate) (min, max exchangerates.DateRate) {
+	for i, r := range rates {
+		if i == 0 || r.Rate < min.Rate {
+			min = r
+		}
+		if i == 0 || r.Rate > max.Rate {
+			max = r
+		}
+	}
+	return
+}
+
+// ZScores returns how many standard deviations every rate lies from the mean of the series
+func ZScores(rates []exchangerates.DateRate) []exchangerates.DateRate {
+	m, sd := mean(rates), Volatility(rates)
+	scores := make([]exchangerates.DateRate, len(rates))
+	for i, r := range rates {
+		scores[i].Date = r.Date
+		if sd != 0 {
+			scores[i].Rate = (r.Rate - m) / sd
+		}
+	}
+	return scores
+}
+
+// Correlation returns the Pearson correlation of the log returns of two series over the days present in both
+func Correlation(a, b []exchangerates.DateRate) (float64, error) {
+	a, b = series.Align(a, b)
+	ra, rb := LogReturns(a), LogReturns(b)
+	if len(ra) < 2 {
+		return 0, errors.New("not enough common dates to correlate")
+	}
+
+	ma, mb := mean(ra), mean(rb)
+	var cov, va, vb float64
+	for i := range ra {
+		da, db := ra[i].Rate-ma, rb[i].Rate-mb
+		cov += da * db
+		va += da * da
+		vb += db * db
+	}
+	if va == 0 || vb == 0 {
+		return 0, errors.New("series without variation cannot be correlated")
+	}
+	return cov / math.Sqrt(va*vb), nil
+}
+
+func mean(rates []exchangerates.DateRate) float64 {
+	if len(rates) == 0 {
+		return 0
+	}
+	sum := 0.0
+	for _, r := range rates {
+		sum += r.Rate
+	}
+	return sum / float64(len(rates))
+}
@@ -0,0 +1,101 @@
+package analytics
+
+import (
+	"math"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+var testRates = []exchangerates.DateRate{
+	{Date: date("2017-03-01"), Rate: 1.00},
+	{Date: date("2017-03-02"), Rate: 1.10},
+	{Date: date("2017-03-03"), Rate: 0.99},
+	{Date: date("2017-03-06"), Rate: 1.05},
+	{Date: date("2017-03-07"), Rate: 1.20},
+}
+
+func round(v float64) float64 {
+	return math.Floor(v*1e6+0.5) / 1e6
+}
+
+func TestSimpleReturns(t *testing.T) {
+	var got []float64
+	for _, r := range SimpleReturns(testRates) {
+		got = append(got, round(r.Rate))
+	}
+	if want := []float64{0.1, -0.1, 0.060606, 0.142857}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestVolatility(t *testing.T) {
+	returns := []exchangerates.DateRate{{Rate: 0.01}, {Rate: -0.01}, {Rate: 0.02}, {Rate: 0}}
+	if want, got := 0.012910, round(Volatility(returns)); want != got {
+		t.Fatalf("expected volatility=%v, got %v", want, got)
+	}
+	if want, got := 0.204939, round(AnnualisedVolatility(returns, TradingDays)); want != got {
+		t.Fatalf("expected annualised volatility=%v, got %v", want, got)
+	}
+	if want, got := 3, len(RollingVolatility(returns, 2)); want != got {
+		t.Fatalf("expected %v rolling values, got %v", want, got)
+	}
+}
+
+func TestMaxDrawdown(t *testing.T) {
+	dd := MaxDrawdown(testRates)
+	if want, got := testRates[1], dd.Peak; want != got {
+		t.Fatalf("expected peak=%v, got %v", want, got)
+	}
+	if want, got := testRates[2], dd.Trough; want != got {
+		t.Fatalf("expected trough=%v, got %v", want, got)
+	}
+	if want, got := 0.1, round(dd.Value); want != got {
+		t.Fatalf("expected drawdown=%v, got %v", want, got)
+	}
+}
+
+func TestMinMax(t *testing.T) {
+	min, max := MinMax(testRates)
+	if min != testRates[2] || max != testRates[4] {
+		t.Fatalf("expected min=%v max=%v, got min=%v max=%v", testRates[2], testRates[4], min, max)
+	}
+}
+
+func TestCorrelation(t *testing.T) {
+	inverse := make([]exchangerates.DateRate, len(testRates))
+	for i, r := range testRates {
+		inverse[i] = exchangerates.DateRate{Date: r.Date, Rate: 1 / r.Rate}
+	}
+
+	corr, err := Correlation(testRates, inverse)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := -1.0, round(corr); want != got {
+		t.Fatalf("expected correlation=%v, got %v", want, got)
+	}
+
+	_, err = Correlation(testRates[:2], inverse[3:])
+	if err == nil {
+		t.Fatal("expected error for series without common dates")
+	}
+}
+
+func TestZScores(t *testing.T) {
+	scores := ZScores([]exchangerates.DateRate{{Rate: 1}, {Rate: 2}, {Rate: 3}})
+	var got []float64
+	for _, s := range scores {
+		got = append(got, s.Rate)
+	}
+	if want := []float64{-1, 0, 1}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
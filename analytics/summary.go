@@ -0,0 +1,44 @@
+package analytics
+
+import (
+	"errors"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Summary collects the statistics of a single exchange rate series
+type Summary struct {
+	Min                  exchangerates.DateRate
+	Max                  exchangerates.DateRate
+	MaxDrawdown          Drawdown
+	Volatility           float64
+	AnnualisedVolatility float64
+	Returns              []exchangerates.DateRate
+	RollingVolatility    []exchangerates.DateRate
+	ZScores              []exchangerates.DateRate
+}
+
+// Summarize computes the statistics of a daily series, using log returns when logReturns is set
+// and windows of the given number of returns for the rolling volatility
+func Summarize(rates []exchangerates.DateRate, window int, logReturns bool) (*Summary, error) {
+	if len(rates) < 2 {
+		return nil, errors.New("at least two rates are needed")
+	}
+	if window < 2 {
+		return nil, errors.New("window should be at least 2")
+	}
+
+	s := &Summary{}
+	if logReturns {
+		s.Returns = LogReturns(rates)
+	} else {
+		s.Returns = SimpleReturns(rates)
+	}
+	s.Min, s.Max = MinMax(rates)
+	s.MaxDrawdown = MaxDrawdown(rates)
+	s.Volatility = Volatility(s.Returns)
+	s.AnnualisedVolatility = AnnualisedVolatility(s.Returns, TradingDays)
+	s.RollingVolatility = RollingVolatility(s.Returns, window)
+	s.ZScores = ZScores(rates)
+	return s, nil
+}
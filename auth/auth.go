@@ -0,0 +1,188 @@
+// Package auth checks API keys and applies per-key rate limits and daily quotas
+package auth
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"time"
+)
+
+// ErrUnknownKey is returned when a request is made with a key that does not exist
+var ErrUnknownKey = errors.New("invalid API key")
+
+// Key is an API key and the limits applied to the requests made with it
+type Key struct {
+	Key   string
+	Name  string
+	Rate  float64 // requests per second allowed on average, unlimited when zero
+	Burst int     // requests allowed at once when the key has been idle, at least one
+	Quota int64   // requests allowed per UTC day, unlimited when zero
+}
+
+// Usage counts the requests made with a key
+type Usage struct {
+	Name     string
+	Requests int64 // requests allowed since the Manager was created
+	Limited  int64 // requests rejected by the rate limit or the quota
+	Today    int64 // requests counted against today's quota
+	Quota    int64
+}
+
+// LimitError is returned when a key has exceeded its rate limit or its quota
+type LimitError struct {
+	Quota      bool          // set when the daily quota is used up rather than the rate limit
+	RetryAfter time.Duration // wait before the key can be used again
+}
+
+func (e *LimitError) Error() string {
+	if e.Quota {
+		return fmt.Sprintf("daily quota exceeded, retry in %v", e.RetryAfter)
+	}
+	return fmt.Sprintf("rate limit exceeded, retry in %v", e.RetryAfter)
+}
+
+// bucket is the token bucket and the counters of a key
+type bucket struct {
+	Key
+	tokens float64
+	last   time.Time
+	day    string
+	usage  Usage
+}
+
+// Manager holds the API keys loaded from a Source and the state of their limits
+type Manager struct {
+	mu      sync.Mutex
+	source  Source
+	buckets map[string]*bucket
+	now     func() time.Time
+}
+
+// New returns a Manager with the keys of source
+func New(source Source) (*Manager, error) {
+	m := &Manager{source: source, buckets: map[string]*bucket{}, now: time.Now}
+	err := m.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// Reload loads the keys from the source again. Keys that still exist keep their usage and tokens
+func (m *Manager) Reload() error {
+	keys, err := m.source.Keys()
+	if err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	buckets := make(map[string]*bucket, len(keys))
+	for _, k := range keys {
+		if k.Key == "" {
+			return errors.New("API key " + k.Name + " is empty")
+		}
+		if k.Burst < 1 {
+			k.Burst = 1
+		}
+
+		b, ok := m.buckets[k.Key]
+		if !ok {
+			b = &bucket{tokens: float64(k.Burst), last: m.now()}
+		}
+		b.Key = k
+		b.tokens = math.Min(b.tokens, float64(k.Burst))
+		b.usage.Name = k.Name
+		b.usage.Quota = k.Quota
+		buckets[k.Key] = b
+	}
+	m.buckets = buckets
+	return nil
+}
+
+// Allow counts a request made with key, returning ErrUnknownKey if the key does not exist
+// and a *LimitError if the key has exceeded its limits
+func (m *Manager) Allow(key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	b, ok := m.buckets[key]
+	if !ok {
+		return ErrUnknownKey
+	}
+
+	now := m.now().UTC()
+	if day := now.Format("2006-01-02"); day != b.day {
+		b.day = day
+		b.usage.Today = 0
+	}
+	if b.Quota > 0 && b.usage.Today >= b.Quota {
+		b.usage.Limited++
+		midnight := now.Truncate(24 * time.Hour).Add(24 * time.Hour)
+		return &LimitError{Quota: true, RetryAfter: midnight.Sub(now)}
+	}
+
+	if b.Rate > 0 {
+		if elapsed := now.Sub(b.last); elapsed > 0 {
+			b.tokens = math.Min(b.tokens+elapsed.Seconds()*b.Rate, float64(b.Burst))
+		}
+		b.last = now
+		if b.tokens < 1 {
+			b.usage.Limited++
+			wait := time.Duration((1 - b.tokens) / b.Rate * float64(time.Second))
+			return &LimitError{RetryAfter: wait}
+		}
+		b.tokens--
+	}
+
+	b.usage.Requests++
+	b.usage.Today++
+	return nil
+}
+
+// Name returns the name of key
+func (m *Manager) Name(key string) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[key]
+	if !ok {
+		return "", ErrUnknownKey
+	}
+	return b.Name, nil
+}
+
+// Usage returns the usage counters of key
+func (m *Manager) Usage(key string) (Usage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	b, ok := m.buckets[key]
+	if !ok {
+		return Usage{}, ErrUnknownKey
+	}
+
+	usage := b.usage
+	if b.day != m.now().UTC().Format("2006-01-02") {
+		usage.Today = 0
+	}
+	return usage, nil
+}
+
+// AllUsage returns the usage counters of every key
+func (m *Manager) AllUsage() []Usage {
+	m.mu.Lock()
+	keys := make([]string, 0, len(m.buckets))
+	for key := range m.buckets {
+		keys = append(keys, key)
+	}
+	m.mu.Unlock()
+
+	usage := make([]Usage, 0, len(keys))
+	for _, key := range keys {
+		if u, err := m.Usage(key); err == nil {
+			usage = append(usage, u)
+		}
+	}
+	return usage
+}
@@ -0,0 +1,137 @@
+package auth
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type staticSource []Key
+
+func (s staticSource) Keys() ([]Key, error) {
+	return s, nil
+}
+
+func newTestManager(t *testing.T, keys ...Key) (*Manager, *time.Time) {
+	m, err := New(staticSource(keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2017, 3, 4, 12, 0, 0, 0, time.UTC)
+	m.now = func() time.Time {
+		return now
+	}
+	return m, &now
+}
+
+func TestRateLimit(t *testing.T) {
+	m, now := newTestManager(t, Key{Key: "k1", Name: "reporting", Rate: 2, Burst: 3})
+
+	for i := 0; i < 3; i++ {
+		if err := m.Allow("k1"); err != nil {
+			t.Fatalf("request %d of the burst failed: %v", i, err)
+		}
+	}
+
+	err := m.Allow("k1")
+	le, ok := err.(*LimitError)
+	if !ok || le.Quota || le.RetryAfter != 500*time.Millisecond {
+		t.Fatalf("expected a rate limit error with a 500ms wait, got %v", err)
+	}
+
+	*now = now.Add(500 * time.Millisecond)
+	if err := m.Allow("k1"); err != nil {
+		t.Errorf("expected a token after 500ms, got %v", err)
+	}
+
+	if err := m.Allow("nope"); err != ErrUnknownKey {
+		t.Errorf("expected error=%v, got %v", ErrUnknownKey, err)
+	}
+
+	usage, err := m.Usage("k1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := Usage{Name: "reporting", Requests: 4, Limited: 1, Today: 4}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("expected usage=%+v, got %+v", expected, usage)
+	}
+}
+
+func TestQuota(t *testing.T) {
+	m, now := newTestManager(t, Key{Key: "k1", Name: "batch", Quota: 2})
+
+	for i := 0; i < 2; i++ {
+		if err := m.Allow("k1"); err != nil {
+			t.Fatalf("request %d failed: %v", i, err)
+		}
+	}
+
+	err := m.Allow("k1")
+	le, ok := err.(*LimitError)
+	if !ok || !le.Quota || le.RetryAfter != 12*time.Hour {
+		t.Fatalf("expected a quota error with a 12h wait, got %v", err)
+	}
+
+	*now = now.Add(12 * time.Hour)
+	if err := m.Allow("k1"); err != nil {
+		t.Errorf("expected the quota to reset the next day, got %v", err)
+	}
+
+	usage, _ := m.Usage("k1")
+	expected := Usage{Name: "batch", Requests: 3, Limited: 1, Today: 1, Quota: 2}
+	if !reflect.DeepEqual(usage, expected) {
+		t.Errorf("expected usage=%+v, got %+v", expected, usage)
+	}
+}
+
+func TestFileReload(t *testing.T) {
+	dir, err := ioutil.TempDir("", "auth")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "keys.json")
+
+	err = ioutil.WriteFile(path, []byte(`[{"key": "k1", "name": "one"}, {"key": "k2", "name": "two"}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m, err := New(File(path))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Allow("k1"); err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(path, []byte(`[{"key": "k1", "name": "renamed", "quota": 1}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = m.Reload()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if err := m.Allow("k2"); err != ErrUnknownKey {
+		t.Errorf("expected removed key to be rejected, got %v", err)
+	}
+	if _, ok := m.Allow("k1").(*LimitError); !ok {
+		t.Errorf("expected usage to be kept across reloads")
+	}
+	if name, _ := m.Name("k1"); name != "renamed" {
+		t.Errorf("expected name=renamed, got %v", name)
+	}
+
+	err = ioutil.WriteFile(path, []byte(`[{"name": "keyless"}]`), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Reload(); err == nil {
+		t.Errorf("expected an error for a key without a value")
+	}
+}
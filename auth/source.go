@@ -0,0 +1,58 @@
+package auth
+
+import (
+	"encoding/json"
+	"os"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Schema is the table the SQL source reads the keys from
+const Schema = `
+CREATE TABLE APIKey (
+    apiKey VARCHAR(64) PRIMARY KEY,
+    name VARCHAR(255),
+    rate DOUBLE,
+    burst INT,
+    quota BIGINT
+);`
+
+// Source loads API keys
+type Source interface {
+	Keys() ([]Key, error)
+}
+
+type fileSource string
+
+// File returns a Source reading the keys from a JSON file holding an array of keys, such as
+// [{"key": "3f9c...", "name": "reporting", "rate": 5, "burst": 10, "quota": 10000}]
+func File(path string) Source {
+	return fileSource(path)
+}
+
+func (f fileSource) Keys() ([]Key, error) {
+	file, err := os.Open(string(f))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var keys []Key
+	err = json.NewDecoder(file).Decode(&keys)
+	return keys, err
+}
+
+type sqlSource struct {
+	db *sqlx.DB
+}
+
+// SQL returns a Source reading the keys from the APIKey table of db, see Schema
+func SQL(db *sqlx.DB) Source {
+	return sqlSource{db: db}
+}
+
+func (s sqlSource) Keys() ([]Key, error) {
+	var keys []Key
+	err := s.db.Select(&keys, "SELECT apiKey AS `key`, name, rate, burst, quota FROM APIKey")
+	return keys, err
+}
@@ -0,0 +1,42 @@
+package exchangerates
+
+import "sync"
+
+// Query represents a single exchange rate lookup
+type Query struct {
+	From string
+	To   string
+	Date string
+}
+
+// Result holds the outcome of a Query
+type Result struct {
+	Rate float64
+	Err  error
+}
+
+// Batch runs the queries against the store with at most concurrency lookups in flight
+// and returns their results in the same order as the queries
+func Batch(s Store, queries []Query, concurrency int) []Result {
+	if concurrency < 1 {
+		concurrency = 1
+	}
+
+	results := make([]Result, len(queries))
+	sem := make(chan struct{}, concurrency)
+	var wg sync.WaitGroup
+	for i, q := range queries {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(i int, q Query) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			rate, err := s.GetExchangeRate(q.From, q.To, q.Date)
+			results[i] = Result{Rate: rate, Err: err}
+		}(i, q)
+	}
+	wg.Wait()
+	return results
+}
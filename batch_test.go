@@ -0,0 +1,59 @@
+package exchangerates_test
+
+import (
+	"errors"
+	"reflect"
+	"sync"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+func TestBatch(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		inFlight int
+		maxSeen  int
+	)
+	s := mock.New()
+	s.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		mu.Lock()
+		inFlight++
+		if inFlight > maxSeen {
+			maxSeen = inFlight
+		}
+		mu.Unlock()
+		defer func() {
+			mu.Lock()
+			inFlight--
+			mu.Unlock()
+		}()
+
+		if to == "XYZ" {
+			return 0, errors.New("currency XYZ not found")
+		}
+		return map[string]float64{"USD": 1.0514, "GBP": 0.85}[to], nil
+	}
+
+	queries := []exchangerates.Query{
+		{From: "EUR", To: "USD", Date: "2017-03-02"},
+		{From: "EUR", To: "XYZ", Date: "2017-03-02"},
+		{From: "EUR", To: "GBP", Date: "2017-03-02"},
+		{From: "EUR", To: "USD", Date: "2017-03-03"},
+	}
+	expected := []exchangerates.Result{
+		{Rate: 1.0514},
+		{Err: errors.New("currency XYZ not found")},
+		{Rate: 0.85},
+		{Rate: 1.0514},
+	}
+
+	got := exchangerates.Batch(s, queries, 2)
+	if !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected results=%v, got %v", expected, got)
+	}
+	if maxSeen > 2 {
+		t.Fatalf("expected at most 2 concurrent lookups, got %d", maxSeen)
+	}
+}
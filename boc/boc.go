@@ -0,0 +1,93 @@
+package boc
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates/table"
+)
+
+// dataURL gives no start_date, so that Valet returns the group from its first observation
+const dataURL = "https://www.bankofcanada.ca/valet/observations/group/FX_RATES_DAILY/json"
+
+const base = "CAD"
+
+// Store fetches and stores historical currency exchange data from the Bank of Canada Valet API
+type Store struct {
+	*table.Store
+}
+
+// New returns a new instance of Store
+func New() *Store {
+	s := newStore()
+	s.Refresh()
+	return s
+}
+
+func newStore() *Store {
+	return &Store{table.New(base, dataURL, parse)}
+}
+
+// seriesCurrency returns the currency quoted by a Valet FX series name such as FXUSDCAD
+func seriesCurrency(series string) (string, bool) {
+	if len(series) != 8 || !strings.HasPrefix(series, "FX") || !strings.HasSuffix(series, base) {
+		return "", false
+	}
+	return series[2:5], true
+}
+
+// parse reads a Valet observations response for the FX_RATES_DAILY group.
+// Valet quotes Canadian dollars per unit of currency, so values are inverted
+// to express them in units of currency per Canadian dollar
+func parse(r io.Reader) (currencies map[string]bool, rates map[string]map[string]float64, err error) {
+	type observation struct {
+		V string `json:"v"`
+	}
+	var data struct {
+		SeriesDetail map[string]json.RawMessage   `json:"seriesDetail"`
+		Observations []map[string]json.RawMessage `json:"observations"`
+	}
+
+	err = json.NewDecoder(r).Decode(&data)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	currencies = make(map[string]bool)
+	for series := range data.SeriesDetail {
+		if curr, ok := seriesCurrency(series); ok {
+			currencies[curr] = true
+		}
+	}
+
+	rates = make(map[string]map[string]float64)
+	for _, obs := range data.Observations {
+		var date string
+		err = json.Unmarshal(obs["d"], &date)
+		if err != nil {
+			return nil, nil, errors.New("observation without date")
+		}
+
+		values := make(map[string]float64)
+		for series, raw := range obs {
+			curr, ok := seriesCurrency(series)
+			if !ok {
+				continue
+			}
+			var o observation
+			if json.Unmarshal(raw, &o) != nil {
+				continue
+			}
+			value, err := strconv.ParseFloat(o.V, 64)
+			if err != nil || value == 0 {
+				continue
+			}
+			values[curr] = 1 / value
+		}
+		rates[date] = values
+	}
+	return currencies, rates, nil
+}
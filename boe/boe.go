@@ -0,0 +1,96 @@
+package boe
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates/table"
+)
+
+const dataURL = "https://www.bankofengland.co.uk/boeapps/iadb/fromshowcolumns.asp?csv.x=yes&Datefrom=01/Jan/1999&Dateto=now&SeriesCodes=XUDLUSS,XUDLERS,XUDLJYS,XUDLCDS,XUDLSFS,XUDLADS,XUDLNDS,XUDLSKS,XUDLNKS,XUDLDKS,XUDLHDS,XUDLSGS,XUDLZRS&CSVF=TN&UsingCodes=Y&VPD=Y&VFD=N"
+
+const base = "GBP"
+
+// seriesCurrencies maps the Bank of England spot rate series codes to currency names
+var seriesCurrencies = map[string]string{
+	"XUDLUSS": "USD",
+	"XUDLERS": "EUR",
+	"XUDLJYS": "JPY",
+	"XUDLCDS": "CAD",
+	"XUDLSFS": "CHF",
+	"XUDLADS": "AUD",
+	"XUDLNDS": "NZD",
+	"XUDLSKS": "SEK",
+	"XUDLNKS": "NOK",
+	"XUDLDKS": "DKK",
+	"XUDLHDS": "HKD",
+	"XUDLSGS": "SGD",
+	"XUDLZRS": "ZAR",
+}
+
+// Store fetches and stores historical currency exchange data from the Bank of England database
+type Store struct {
+	*table.Store
+}
+
+// New returns a new instance of Store
+func New() *Store {
+	s := newStore()
+	s.Refresh()
+	return s
+}
+
+func newStore() *Store {
+	return &Store{table.New(base, dataURL, parse)}
+}
+
+// parse reads a Bank of England database CSV export with series codes as column headers.
+// Values are quoted in units of currency per pound sterling
+func parse(r io.Reader) (currencies map[string]bool, rates map[string]map[string]float64, err error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(records) == 0 || len(records[0]) == 0 || records[0][0] != "DATE" {
+		return nil, nil, errors.New(`"DATE" header not found`)
+	}
+
+	currencies = make(map[string]bool)
+	columns := make(map[int]string) //maps column indexes to currency names
+	for i := 1; i < len(records[0]); i++ {
+		curr, ok := seriesCurrencies[records[0][i]]
+		if !ok {
+			continue
+		}
+		columns[i] = curr
+		currencies[curr] = true
+	}
+
+	rates = make(map[string]map[string]float64)
+	for _, record := range records[1:] {
+		t, err := time.Parse("02 Jan 2006", record[0])
+		if err != nil {
+			return nil, nil, errors.New("invalid date " + record[0])
+		}
+
+		values := make(map[string]float64)
+		for i, curr := range columns {
+			if i >= len(record) {
+				continue
+			}
+			value, err := strconv.ParseFloat(record[i], 64)
+			if err != nil || value == 0 {
+				continue
+			}
+			values[curr] = value
+		}
+		rates[t.Format("2006-01-02")] = values
+	}
+	return currencies, rates, nil
+}
@@ -0,0 +1,82 @@
+package boe
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func TestGetExchangeRate(t *testing.T) {
+	var tests = []struct {
+		From         string
+		To           string
+		Date         string
+		ExpectedErr  error
+		ExpectedRate float64
+	}{
+		{
+			From:         "GBP",
+			To:           "USD",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 1.225,
+		},
+		{
+			From:         "USD",
+			To:           "EUR",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 0.95241,
+		},
+		{
+			From:         "EUR",
+			To:           "JPY",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 119.99657,
+		},
+		{
+			From:         "GBP",
+			To:           "EUR",
+			Date:         "2017-03-03",
+			ExpectedErr:  exchangerates.NotFound("EUR data does not exist for 2017-03-03"),
+			ExpectedRate: 0,
+		},
+		{
+			From:         "USD",
+			To:           "XYZ",
+			Date:         "2017-03-02",
+			ExpectedErr:  exchangerates.NotFound("currency XYZ not found"),
+			ExpectedRate: 0,
+		},
+		{
+			From:         "USD",
+			To:           "GBP",
+			Date:         "9999-03-02",
+			ExpectedErr:  exchangerates.NotFound("date not found"),
+			ExpectedRate: 0,
+		},
+	}
+
+	f, err := os.Open("testdata/iadb.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := newStore()
+	if err := s.Load(f); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		got, err := s.GetExchangeRate(tt.From, tt.To, tt.Date)
+		if want, got := tt.ExpectedErr, err; !reflect.DeepEqual(want, got) {
+			t.Fatalf("#%d failed: expected error=%v, got %v", i, want, got)
+		}
+		if want, got := tt.ExpectedRate, got; want != got {
+			t.Fatalf("#%d failed: expected rate=%v, got %v", i, want, got)
+		}
+	}
+}
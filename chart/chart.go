@@ -2,22 +2,184 @@ package chart
 
 import (
 	"bytes"
+	"errors"
+	"fmt"
 	"image"
 	"image/gif"
 	"io"
+	"regexp"
+	"strings"
 	"time"
 
 	"github.com/farhan-shahid/exchangerates"
 	gochart "github.com/wcharczuk/go-chart"
+	"github.com/wcharczuk/go-chart/drawing"
 )
 
-// MakeRateChartGIF writes a GIF graphical representation of the exchange rate data to the io.Writer provided
+// Format is the image format of a chart
+type Format string
+
+// Formats charts are written in
+const (
+	PNG Format = "png"
+	SVG Format = "svg"
+	GIF Format = "gif" // animated, drawing the rates one after the other
+)
+
+// ParseFormat returns the format named s
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case PNG, SVG, GIF:
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown chart format %q, should be one of png, svg or gif", s)
+}
+
+// ContentType returns the MIME type of charts in the format f
+func (f Format) ContentType() string {
+	switch f {
+	case SVG:
+		return "image/svg+xml"
+	case GIF:
+		return "image/gif"
+	}
+	return "image/png"
+}
+
+// Theme is the colour scheme of a chart
+type Theme string
+
+// Themes charts are drawn with
+const (
+	Light Theme = "light"
+	Dark  Theme = "dark"
+)
+
+type colours struct {
+	background, text, axis, grid drawing.Color
+	line                         drawing.Color   // the line of rate charts
+	series                       []drawing.Color // the lines of comparison charts, in turn
+}
+
+var themes = map[Theme]colours{
+	Light: {
+		background: gochart.ColorWhite,
+		text:       gochart.ColorBlack,
+		axis:       gochart.ColorBlack,
+		grid:       gochart.ColorLightGray,
+		line:       gochart.ColorBlack.WithAlpha(128),
+		series:     gochart.DefaultColors,
+	},
+	Dark: {
+		background: drawing.ColorFromHex("1e1e1e"),
+		text:       drawing.ColorFromHex("e0e0e0"),
+		axis:       drawing.ColorFromHex("a0a0a0"),
+		grid:       drawing.ColorFromHex("3c3c3c"),
+		line:       drawing.ColorFromHex("8ab4f8"),
+		series: []drawing.Color{
+			drawing.ColorFromHex("8ab4f8"),
+			drawing.ColorFromHex("81c995"),
+			drawing.ColorFromHex("f28b82"),
+			drawing.ColorFromHex("fdd663"),
+			drawing.ColorFromHex("c58af9"),
+			drawing.ColorFromHex("78d9ec"),
+		},
+	},
+}
+
+// Limits of the size of charts, beyond which drawing them takes too much memory
+const (
+	MaxSize = 4096
+	MaxDPI  = 600
+)
+
+// Options set how a chart is drawn. Zero values stand for a PNG chart of go-chart's default size and DPI,
+// drawn with the light theme without title nor gridlines, its rates being labelled "FROM to TO exchange rate"
+type Options struct {
+	Format     Format
+	Width      int
+	Height     int
+	DPI        float64
+	Theme      Theme
+	LineColor  string // a CSS hex colour such as #1f77b4 replacing the one of the theme
+	Title      string
+	XLabel     string
+	YLabel     string
+	DateFormat string // a Go time layout such as "Jan 2" the dates of the X axis are written with
+	Grid       bool
+}
+
+var hexColourRe = regexp.MustCompile(`^#?([0-9a-fA-F]{3}|[0-9a-fA-F]{6})$`)
+
+// Validate returns an error describing the first option that is out of range or unknown
+func (o Options) Validate() error {
+	if o.Format != "" {
+		if _, err := ParseFormat(string(o.Format)); err != nil {
+			return err
+		}
+	}
+	if o.Width < 0 || o.Width > MaxSize || o.Height < 0 || o.Height > MaxSize {
+		return fmt.Errorf("the width and height of a chart should not be negative nor above %d pixels", MaxSize)
+	}
+	if o.DPI < 0 || o.DPI > MaxDPI {
+		return fmt.Errorf("the DPI of a chart should not be negative nor above %d", MaxDPI)
+	}
+	if _, ok := themes[o.Theme]; o.Theme != "" && !ok {
+		return fmt.Errorf("unknown theme %q, should be one of light or dark", o.Theme)
+	}
+	if o.LineColor != "" && !hexColourRe.MatchString(o.LineColor) {
+		return fmt.Errorf("incorrect colour %q, should be similar to #1f77b4", o.LineColor)
+	}
+	// layouts without any element, such as "date", format every date the same
+	ref := time.Date(2017, 3, 2, 15, 4, 5, 0, time.UTC)
+	if o.DateFormat != "" && ref.Format(o.DateFormat) == o.DateFormat {
+		return fmt.Errorf("incorrect date format %q, should be a Go time layout such as Jan 2 or 2006-01-02", o.DateFormat)
+	}
+	return nil
+}
+
+// errTooFewRates is returned when charting fewer than two exchange rates, which do not make a line
+var errTooFewRates = errors.New("at least two exchange rates are needed to draw a chart")
+
+// MakeRateChart writes a PNG graphical representation of the exchange rate data to the io.Writer provided
+func MakeRateChart(from, to string, rates []exchangerates.DateRate, w io.Writer) error {
+	return MakeRateChartOptions(from, to, rates, w, Options{Format: PNG})
+}
+
+// MakeRateChartGIF writes an animated GIF graphical representation of the exchange rate data to the io.Writer provided
 func MakeRateChartGIF(from, to string, rates []exchangerates.DateRate, w io.Writer) error {
+	return MakeRateChartOptions(from, to, rates, w, Options{Format: GIF})
+}
+
+// MakeRateChartOptions writes a graphical representation of the exchange rate data to the io.Writer provided,
+// drawn as set by opts. Charts are written as PNG unless opts asks for SVG or GIF
+func MakeRateChartOptions(from, to string, rates []exchangerates.DateRate, w io.Writer, opts Options) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if len(rates) < 2 {
+		return errTooFewRates
+	}
+	switch opts.Format {
+	case SVG:
+		return makeRateChartPartial(from, to, rates, w, len(rates), gochart.SVG, opts)
+	case GIF:
+		return makeRateChartGIF(from, to, rates, w, opts)
+	}
+	return makeRateChartPartial(from, to, rates, w, len(rates), gochart.PNG, opts)
+}
+
+func makeRateChartGIF(from, to string, rates []exchangerates.DateRate, w io.Writer, opts Options) error {
 	finalGIF := &gif.GIF{}
 	var b bytes.Buffer
 
-	for i := 2; i < len(rates); i++ {
-		err := makeRateChartPartial(from, to, rates, &b, i+1)
+	// the first frame draws three rates, or the two there are
+	first := 2
+	if len(rates) < 3 {
+		first = 1
+	}
+	for i := first; i < len(rates); i++ {
+		err := makeRateChartPartial(from, to, rates, &b, i+1, gochart.PNG, opts)
 		if err != nil {
 			return err
 		}
@@ -47,12 +209,8 @@ func MakeRateChartGIF(from, to string, rates []exchangerates.DateRate, w io.Writ
 	return gif.EncodeAll(w, finalGIF)
 }
 
-// MakeRateChart writes a PNG graphical representation of the exchange rate data to the io.Writer provided
-func MakeRateChart(from, to string, rates []exchangerates.DateRate, w io.Writer) error {
-	return makeRateChartPartial(from, to, rates, w, len(rates))
-}
-
-func makeRateChartPartial(from, to string, rates []exchangerates.DateRate, w io.Writer, length int) error {
+func makeRateChartPartial(from, to string, rates []exchangerates.DateRate, w io.Writer, length int,
+	rp gochart.RendererProvider, opts Options) error {
 	dates := make([]time.Time, length)
 	vals := make([]float64, length)
 
@@ -61,25 +219,73 @@ func makeRateChartPartial(from, to string, rates []exchangerates.DateRate, w io.
 		vals[i] = rates[i].Rate
 	}
 
+	graph, c := newChart(opts)
+	if graph.YAxis.Name == "" {
+		graph.YAxis.Name = from + " to " + to + " exchange rate"
+	}
+	if opts.LineColor == "" {
+		c.series[0] = c.line
+	}
+	graph.Series = []gochart.Series{
+		gochart.TimeSeries{
+			Style: gochart.Style{
+				Show:        true,
+				StrokeColor: c.series[0],
+			},
+			XValues: dates,
+			YValues: vals,
+		},
+	}
+	return graph.Render(rp, w)
+}
+
+// newChart returns a chart without series drawn as set by opts, along with the colours of its theme. The
+// first colour of the series is the line colour of opts, when given
+func newChart(opts Options) (gochart.Chart, colours) {
+	c := themes[Light]
+	if t, ok := themes[opts.Theme]; ok {
+		c = t
+	}
+	c.series = append([]drawing.Color(nil), c.series...)
+	if opts.LineColor != "" {
+		c.series[0] = drawing.ColorFromHex(strings.TrimPrefix(opts.LineColor, "#"))
+	}
+	axisStyle := gochart.Style{Show: true, StrokeColor: c.axis, FontColor: c.text}
+	nameStyle := gochart.Style{Show: true, FontColor: c.text}
+	gridStyle := gochart.Style{Show: opts.Grid, StrokeColor: c.grid, StrokeWidth: 1}
+
 	graph := gochart.Chart{
+		Width:      opts.Width,
+		Height:     opts.Height,
+		DPI:        opts.DPI,
+		Background: gochart.Style{FillColor: c.background, StrokeColor: c.background},
+		Canvas:     gochart.Style{FillColor: c.background, StrokeColor: c.background},
 		XAxis: gochart.XAxis{
-			Style: gochart.StyleShow(),
+			Name:           opts.XLabel,
+			NameStyle:      nameStyle,
+			Style:          axisStyle,
+			GridMajorStyle: gridStyle,
 		},
 		YAxis: gochart.YAxis{
-			Name:      from + " to " + to + " exchange rate",
-			NameStyle: gochart.StyleShow(),
-			Style:     gochart.StyleShow(),
-		},
-		Series: []gochart.Series{
-			gochart.TimeSeries{
-				Style: gochart.Style{
-					Show:        true,
-					StrokeColor: gochart.ColorBlack.WithAlpha(128),
-				},
-				XValues: dates,
-				YValues: vals,
-			},
+			Name:           opts.YLabel,
+			NameStyle:      nameStyle,
+			Style:          axisStyle,
+			GridMajorStyle: gridStyle,
 		},
 	}
-	return graph.Render(gochart.PNG, w)
+	if opts.DateFormat != "" {
+		graph.XAxis.ValueFormatter = func(v interface{}) string {
+			if t, ok := v.(float64); ok {
+				return time.Unix(0, int64(t)).UTC().Format(opts.DateFormat)
+			}
+			return gochart.TimeValueFormatterWithFormat(v, opts.DateFormat)
+		}
+	}
+	if opts.Title != "" {
+		graph.Title = opts.Title
+		graph.TitleStyle = gochart.Style{Show: true, FontColor: c.text}
+		// leave room above the canvas for the title
+		graph.Background.Padding = gochart.Box{Top: 50, Left: 5, Right: 5, Bottom: 5}
+	}
+	return graph, c
 }
@@ -0,0 +1,160 @@
+package chart
+
+import (
+	"bytes"
+	"image"
+	_ "image/gif"
+	_ "image/png"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+var testRates = []exchangerates.DateRate{
+	{Date: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), Rate: 1.05},
+	{Date: time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC), Rate: 1.04},
+	{Date: time.Date(2017, 3, 3, 0, 0, 0, 0, time.UTC), Rate: 1.06},
+	{Date: time.Date(2017, 3, 6, 0, 0, 0, 0, time.UTC), Rate: 1.08},
+}
+
+func TestMakeRateChart(t *testing.T) {
+	var tests = []struct {
+		opts           Options
+		ExpectedFormat string
+	}{
+		{Options{}, "png"},
+		{Options{Format: PNG, Width: 300, Height: 200, Title: "EUR/USD"}, "png"},
+		{Options{Format: GIF, Width: 300, Height: 200}, "gif"},
+		{Options{Format: SVG, Width: 300, Height: 200, Title: "EUR/USD"}, "svg"},
+		{Options{Format: SVG, Theme: Dark, LineColor: "#1f77b4", Grid: true, XLabel: "date", DateFormat: "Jan 2", Title: "EUR/USD"}, "svg"},
+		{Options{Width: 600, Height: 300, DPI: 150, Theme: Light, LineColor: "f00", Grid: true}, "png"},
+	}
+
+	for i, tt := range tests {
+		var b bytes.Buffer
+		if err := MakeRateChartOptions("EUR", "USD", testRates, &b, tt.opts); err != nil {
+			t.Errorf("#%d failed: %v", i, err)
+			continue
+		}
+		if tt.ExpectedFormat == "svg" {
+			if svg := b.String(); !strings.HasPrefix(svg, "<svg") || !strings.Contains(svg, "EUR/USD") {
+				t.Errorf("#%d failed: expected an SVG document with a title, got %.40q", i, svg)
+			}
+			continue
+		}
+		cfg, format, err := image.DecodeConfig(&b)
+		if err != nil {
+			t.Errorf("#%d failed: %v", i, err)
+			continue
+		}
+		if format != tt.ExpectedFormat {
+			t.Errorf("#%d failed: expected format %s, got %s", i, tt.ExpectedFormat, format)
+		}
+		if tt.opts.Width != 0 && (cfg.Width != tt.opts.Width || cfg.Height != tt.opts.Height) {
+			t.Errorf("#%d failed: expected %dx%d, got %dx%d", i, tt.opts.Width, tt.opts.Height, cfg.Width, cfg.Height)
+		}
+	}
+}
+
+func TestMakeRateChartFormats(t *testing.T) {
+	for expected, makeChart := range map[string]func(string, string, []exchangerates.DateRate, io.Writer) error{
+		"png": MakeRateChart,
+		"gif": MakeRateChartGIF,
+	} {
+		var b bytes.Buffer
+		if err := makeChart("EUR", "USD", testRates, &b); err != nil {
+			t.Errorf("%s: %v", expected, err)
+			continue
+		}
+		if _, format, err := image.DecodeConfig(&b); err != nil || format != expected {
+			t.Errorf("expected format %s, got %s (%v)", expected, format, err)
+		}
+	}
+}
+
+func TestMakeRateChartErrors(t *testing.T) {
+	var b bytes.Buffer
+	if err := MakeRateChartOptions("EUR", "USD", testRates[:1], &b, Options{Format: GIF}); err != errTooFewRates {
+		t.Errorf("expected %v, got %v", errTooFewRates, err)
+	}
+	if err := MakeRateChartOptions("EUR", "USD", testRates[:2], &b, Options{Format: GIF}); err != nil {
+		t.Errorf("expected two rates to be animated, got %v", err)
+	}
+	if err := MakeRateChartOptions("EUR", "USD", testRates, &b, Options{Format: "jpeg"}); err == nil {
+		t.Error("expected an unknown format to be reported")
+	}
+	if _, err := ParseFormat("svg"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		opts          Options
+		ExpectedError string
+	}{
+		{Options{Width: 5000}, "the width and height of a chart should not be negative nor above 4096 pixels"},
+		{Options{Height: -1}, "the width and height of a chart should not be negative nor above 4096 pixels"},
+		{Options{DPI: 1000}, "the DPI of a chart should not be negative nor above 600"},
+		{Options{Theme: "solarized"}, `unknown theme "solarized", should be one of light or dark`},
+		{Options{LineColor: "blue"}, `incorrect colour "blue", should be similar to #1f77b4`},
+		{Options{DateFormat: "date"}, `incorrect date format "date", should be a Go time layout such as Jan 2 or 2006-01-02`},
+		{Options{Format: "jpeg"}, `unknown chart format "jpeg", should be one of png, svg or gif`},
+	}
+
+	for i, tt := range tests {
+		if err := tt.opts.Validate(); err == nil || err.Error() != tt.ExpectedError {
+			t.Errorf("#%d failed: expected error=%q, got %v", i, tt.ExpectedError, err)
+		}
+	}
+}
+
+func TestMakeCompareChart(t *testing.T) {
+	gbp := []exchangerates.DateRate{
+		{Date: time.Date(2017, 3, 1, 0, 0, 0, 0, time.UTC), Rate: 0.85},
+		{Date: time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC), Rate: 0.86},
+		{Date: time.Date(2017, 3, 3, 0, 0, 0, 0, time.UTC), Rate: 0.85},
+	}
+	series := []Series{{Name: "EUR/USD", Rates: testRates}, {Name: "EUR/GBP", Rates: gbp}}
+
+	var b bytes.Buffer
+	if err := MakeCompareChart(series, &b, CompareOptions{Options: Options{Width: 300, Height: 200}, Rebase: true}); err != nil {
+		t.Fatal(err)
+	}
+	if cfg, format, err := image.DecodeConfig(&b); err != nil || format != "png" || cfg.Width != 300 {
+		t.Errorf("expected a 300 pixels wide PNG, got %s %d wide: %v", format, cfg.Width, err)
+	}
+
+	b.Reset()
+	series[1].Secondary = true
+	if err := MakeCompareChart(series, &b, CompareOptions{Options: Options{Format: SVG, Theme: Dark}}); err != nil {
+		t.Fatal(err)
+	}
+	if svg := b.String(); !strings.Contains(svg, "EUR/USD") || !strings.Contains(svg, "EUR/GBP (left axis)") {
+		t.Errorf("expected the legend to name both series, got %.80q", svg)
+	}
+}
+
+func TestMakeCompareChartErrors(t *testing.T) {
+	var b bytes.Buffer
+	var tests = []struct {
+		series        []Series
+		opts          CompareOptions
+		ExpectedError string
+	}{
+		{nil, CompareOptions{}, "a comparison chart draws from 1 to 8 series"},
+		{make([]Series, 9), CompareOptions{}, "a comparison chart draws from 1 to 8 series"},
+		{[]Series{{Name: "EUR/USD", Rates: testRates[:1]}}, CompareOptions{}, "EUR/USD: " + errTooFewRates.Error()},
+		{[]Series{{Name: "EUR/USD", Rates: testRates}}, CompareOptions{Options: Options{Format: GIF}}, "comparison charts are drawn as PNG or SVG"},
+		{[]Series{{Name: "EUR/USD", Rates: testRates}}, CompareOptions{Options: Options{DPI: 1000}}, "the DPI of a chart should not be negative nor above 600"},
+	}
+
+	for i, tt := range tests {
+		if err := MakeCompareChart(tt.series, &b, tt.opts); err == nil || err.Error() != tt.ExpectedError {
+			t.Errorf("#%d failed: expected error=%q, got %v", i, tt.ExpectedError, err)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package chart
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	gochart "github.com/wcharczuk/go-chart"
+)
+
+// MaxSeries is the most series a comparison chart draws, beyond which its lines cannot be told apart
+const MaxSeries = 8
+
+// Series is one named line of a comparison chart, such as the EUR/USD exchange rates
+type Series struct {
+	Name  string
+	Rates []exchangerates.DateRate
+	// Secondary draws the series against the Y axis on the left, for rates of another scale than the
+	// other series such as EUR/JPY next to EUR/USD
+	Secondary bool
+}
+
+// CompareOptions set how a comparison chart is drawn. Rebase draws every series as a percentage of its
+// first rate, so that series of different scales can be compared on the same axis
+type CompareOptions struct {
+	Options
+	Rebase bool
+}
+
+// MakeCompareChart writes a chart of several series of exchange rates, along with a legend naming them, to
+// the io.Writer provided, drawn as set by opts. Charts are written as PNG unless opts asks for SVG
+func MakeCompareChart(series []Series, w io.Writer, opts CompareOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Format == GIF {
+		return errors.New("comparison charts are drawn as PNG or SVG")
+	}
+	if len(series) == 0 || len(series) > MaxSeries {
+		return fmt.Errorf("a comparison chart draws from 1 to %d series", MaxSeries)
+	}
+
+	graph, c := newChart(opts.Options)
+	if graph.YAxis.Name == "" {
+		graph.YAxis.Name = "exchange rate"
+		if opts.Rebase {
+			graph.YAxis.Name = "rebased to 100"
+		}
+	}
+	for i, s := range series {
+		if len(s.Rates) < 2 {
+			return fmt.Errorf("%s: %v", s.Name, errTooFewRates)
+		}
+		dates := make([]time.Time, len(s.Rates))
+		vals := make([]float64, len(s.Rates))
+		for j, r := range s.Rates {
+			dates[j] = r.Date
+			vals[j] = r.Rate
+			if opts.Rebase {
+				vals[j] = r.Rate / s.Rates[0].Rate * 100
+			}
+		}
+
+		ts := gochart.TimeSeries{
+			Name: s.Name,
+			Style: gochart.Style{
+				Show:        true,
+				StrokeColor: c.series[i%len(c.series)],
+			},
+			XValues: dates,
+			YValues: vals,
+		}
+		if s.Secondary {
+			ts.Name += " (left axis)"
+			ts.YAxis = gochart.YAxisSecondary
+			graph.YAxisSecondary.Style = graph.YAxis.Style
+		}
+		graph.Series = append(graph.Series, ts)
+	}
+	graph.Elements = []gochart.Renderable{
+		gochart.Legend(&graph, gochart.Style{FillColor: c.background, FontColor: c.text, StrokeColor: c.axis}),
+	}
+
+	if opts.Format == SVG {
+		return graph.Render(gochart.SVG, w)
+	}
+	return graph.Render(gochart.PNG, w)
+}
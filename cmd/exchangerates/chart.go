@@ -0,0 +1,120 @@
+package main
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/chart"
+)
+
+// chartMain writes a chart of the exchange rates between two dates to a file or to stdout
+func chartMain(args []string) {
+	fs := newFlagSet("chart")
+	var (
+		store  = addStoreFlags(fs)
+		from   = fs.String("from", "EUR", "the currency to convert from")
+		to     = fs.String("to", "USD", "the currency to convert to")
+		start  = fs.String("start", "this month", "the first date charted such as 2017-03-02, or a period such as 2017-03 or last month")
+		end    = fs.String("end", "", "the last date charted, the last day of the -start period when empty")
+		out    = fs.String("out", "", "the file the chart is written to, - for stdout, chart.<format> when empty")
+		format = fs.String("format", "", "the chart `format`: png, svg or gif, guessed from the -out extension when empty, png otherwise")
+		width  = fs.Int("width", 0, "the width of the chart in pixels, 1024 when 0")
+		height = fs.Int("height", 0, "the height of the chart in pixels, 400 when 0")
+		title  = fs.String("title", "", "the title drawn above the chart, none when empty")
+	)
+	fs.StringVar(out, "o", "", "shorthand for -out")
+	fs.Parse(args)
+
+	r, err := parseDate("start", *start)
+	if err != nil {
+		fatal(err)
+	}
+	startDate, endDate := r.Start, r.End
+	if *end != "" {
+		if r, err = parseDate("end", *end); err != nil {
+			fatal(err)
+		}
+		endDate = r.End
+	}
+	if endDate.Before(startDate) {
+		fatal(usagef("the end date is before the start date"))
+	}
+	if *width < 0 || *height < 0 {
+		fatal(usagef("the width and height should not be negative"))
+	}
+	opts := chart.Options{Width: *width, Height: *height, Title: *title}
+	if opts.Format, err = chartFormat(*format, *out); err != nil {
+		fatal(err)
+	}
+	if *out == "" {
+		*out = "chart." + string(opts.Format)
+	}
+
+	name, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+	res, err := writeChart(s, *from, *to, startDate, endDate, *out, opts)
+	if err != nil {
+		fatal(err)
+	}
+	res.Store = name
+	// the chart itself is the output when written to stdout
+	if *out != "-" {
+		show(res)
+	}
+}
+
+// chartFormat returns the format named by the -format flag, or otherwise the one of the file extension, png
+// when it is not one of a chart format
+func chartFormat(name, filename string) (chart.Format, error) {
+	if name == "" {
+		if f, err := chart.ParseFormat(strings.ToLower(strings.TrimPrefix(filepath.Ext(filename), "."))); err == nil {
+			return f, nil
+		}
+		return chart.PNG, nil
+	}
+	f, err := chart.ParseFormat(name)
+	if err != nil {
+		return "", usageError{err}
+	}
+	return f, nil
+}
+
+// writeChart writes a chart of the exchange rates between start and end to filename, or to stdout when it is -
+func writeChart(s exchangerates.Store, from, to string, start, end time.Time, filename string, opts chart.Options) (*chartJSON, error) {
+	rates, err := exchangerates.GetRangeExchangeRates(s, from, to, start, end)
+	if err != nil {
+		return nil, upstream(err)
+	}
+	if len(rates) < 2 {
+		return nil, exchangerates.NotFound("too few exchange rates to chart between " + start.Format("2006-01-02") + " and " + end.Format("2006-01-02"))
+	}
+
+	var w io.WriteCloser = os.Stdout
+	if filename != "-" {
+		if w, err = os.Create(filename); err != nil {
+			return nil, err
+		}
+	}
+	if err = chart.MakeRateChartOptions(from, to, rates, w, opts); err != nil {
+		w.Close()
+		return nil, err
+	}
+	if err = w.Close(); err != nil {
+		return nil, err
+	}
+	return &chartJSON{
+		File:   filename,
+		Format: string(opts.Format),
+		From:   from,
+		To:     to,
+		Start:  start.Format("2006-01-02"),
+		End:    end.Format("2006-01-02"),
+		Points: len(rates),
+	}, nil
+}
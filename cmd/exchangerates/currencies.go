@@ -0,0 +1,91 @@
+package main
+
+import (
+	"sort"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/config"
+)
+
+// currenciesMain lists the currencies quoted by a store on a date
+func currenciesMain(args []string) {
+	fs := newFlagSet("currencies")
+	var (
+		store = addStoreFlags(fs)
+		date  = fs.String("date", "", "the date of the quotes such as 2017-03-02, yesterday or -3d, the latest fixing when empty")
+	)
+	fs.Parse(args)
+	day, period := resolveDate(*date)
+
+	name, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+	currs, err := quotedCurrencies(s, name, day, period)
+	if err != nil {
+		fatal(err)
+	}
+	show(currs)
+}
+
+// quotedCurrencies returns the sorted currencies quoted by a store on date, or on the latest fixing when date is
+// empty or the last day of a period
+func quotedCurrencies(s exchangerates.Store, name, date string, period bool) (currencies, error) {
+	q, ok := s.(exchangerates.Quoter)
+	if !ok {
+		return nil, usagef("%s does not list the currencies it quotes", name)
+	}
+
+	var (
+		quotes []exchangerates.Quote
+		err    error
+	)
+	if date != "" && !period {
+		quotes, err = q.GetQuotes(date)
+	} else {
+		end := time.Now().UTC()
+		if date != "" {
+			end, _ = time.Parse("2006-01-02", date)
+		}
+		for i := 0; i <= lookback; i++ {
+			quotes, err = q.GetQuotes(end.AddDate(0, 0, -i).Format("2006-01-02"))
+			if !exchangerates.IsNotFound(err) {
+				break
+			}
+		}
+	}
+	if err != nil {
+		return nil, upstream(err)
+	}
+
+	seen := make(map[string]bool)
+	for _, quote := range quotes {
+		seen[quote.From] = true
+		seen[quote.To] = true
+	}
+	currs := make(currencies, 0, len(seen))
+	for curr := range seen {
+		currs = append(currs, curr)
+	}
+	sort.Strings(currs)
+	return currs, nil
+}
+
+// storesMain lists the configured stores without loading their datasets
+func storesMain(args []string) {
+	fs := newFlagSet("stores")
+	cfgFile := addConfigFlag(fs)
+	fs.Parse(args)
+
+	cfg, err := config.Load(*cfgFile, nil)
+	if err != nil {
+		fatal(err)
+	}
+
+	res := make(storeList, 0, len(config.StoreNames))
+	for _, name := range config.StoreNames {
+		res = append(res, storeJSON{Name: name, Enabled: cfg.Stores[name].Enabled, Default: name == cfg.DefaultStore})
+	}
+	show(res)
+}
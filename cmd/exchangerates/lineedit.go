@@ -0,0 +1,151 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/crypto/ssh/terminal"
+)
+
+// maxHistory is how many lines are kept in the history
+const maxHistory = 1000
+
+// lineReader reads the lines typed at a prompt. When reading from a terminal, lines can be edited,
+// recalled from the history with the arrow keys and completed with tab
+type lineReader struct {
+	in          *os.File
+	r           *bufio.Reader
+	t           *terminal.Terminal // editing the lines typed in a terminal, nil otherwise
+	interactive bool
+	prompt      string
+
+	// complete returns the words that may replace line[start:pos], the word being typed
+	complete func(line []rune, pos int) (start int, words []string)
+}
+
+// newLineReader returns a lineReader reading from in, loading the history saved to historyFile, if any
+func newLineReader(in *os.File, out io.Writer, historyFile string) *lineReader {
+	lr := &lineReader{
+		in:          in,
+		r:           bufio.NewReader(in),
+		interactive: terminal.IsTerminal(int(in.Fd())),
+	}
+	if !lr.interactive {
+		return lr
+	}
+
+	h := &history{file: historyFile}
+	if historyFile != "" {
+		if f, err := os.Open(historyFile); err == nil {
+			sc := bufio.NewScanner(f)
+			for sc.Scan() {
+				h.add(sc.Text())
+			}
+			f.Close()
+		}
+	}
+	lr.t = terminal.NewTerminal(struct {
+		io.Reader
+		io.Writer
+	}{in, out}, "")
+	lr.t.History = h
+	lr.t.AutoCompleteCallback = lr.autoComplete
+	return lr
+}
+
+// readLine returns the next line without its line ending, or io.EOF once input ends or Ctrl-D is typed on
+// an empty line
+func (lr *lineReader) readLine() (string, error) {
+	if !lr.interactive {
+		line, err := lr.r.ReadString('\n')
+		if err == io.EOF && line != "" {
+			err = nil
+		}
+		return strings.TrimRight(line, "\r\n"), err
+	}
+
+	fd := int(lr.in.Fd())
+	state, err := terminal.MakeRaw(fd)
+	if err != nil {
+		return "", err
+	}
+	defer terminal.Restore(fd, state)
+	if width, height, err := terminal.GetSize(fd); err == nil && width > 0 {
+		lr.t.SetSize(width, height)
+	}
+	lr.t.SetPrompt(lr.prompt)
+	return lr.t.ReadLine()
+}
+
+// autoComplete completes the word before pos when tab is typed and a single word may replace it, or extends
+// it to the prefix shared by the words that may, listing them when it cannot be extended
+func (lr *lineReader) autoComplete(line string, pos int, key rune) (string, int, bool) {
+	if key != '\t' || lr.complete == nil {
+		return "", 0, false
+	}
+	runes := []rune(line)
+	start, words := lr.complete(runes, utf8.RuneCountInString(line[:pos]))
+	if len(words) == 0 {
+		return line, pos, true
+	}
+
+	prefix := words[0]
+	for _, w := range words[1:] {
+		for !strings.HasPrefix(w, prefix) {
+			prefix = prefix[:len(prefix)-1]
+		}
+	}
+	if len(words) > 1 && len(prefix) <= len(line[:pos])-len(string(runes[:start])) {
+		fmt.Fprintln(lr.t, strings.Join(words, "  "))
+		return line, pos, true
+	}
+
+	completed := string(runes[:start]) + prefix
+	return completed + line[pos:], len(completed), true
+}
+
+// history holds the lines recalled with the arrow keys, appending those typed to its file when not empty
+type history struct {
+	lines []string
+	file  string
+}
+
+// Add adds a typed line to the history and saves it. Failing to do so does not prevent the shell from
+// being used
+func (h *history) Add(line string) {
+	if !h.add(line) || h.file == "" {
+		return
+	}
+	f, err := os.OpenFile(h.file, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0600)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(f, line)
+	f.Close()
+}
+
+// add adds line to the history, unless empty or the same as the last one
+func (h *history) add(line string) bool {
+	if line == "" || len(h.lines) > 0 && h.lines[len(h.lines)-1] == line {
+		return false
+	}
+	h.lines = append(h.lines, line)
+	if len(h.lines) > maxHistory {
+		h.lines = h.lines[len(h.lines)-maxHistory:]
+	}
+	return true
+}
+
+// Len returns the number of lines in the history
+func (h *history) Len() int {
+	return len(h.lines)
+}
+
+// At returns the idx-th line from the most recent one
+func (h *history) At(idx int) string {
+	return h.lines[len(h.lines)-1-idx]
+}
@@ -5,11 +5,18 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/farhan-shahid/exchangerates"
-	"github.com/farhan-shahid/exchangerates/chart"
+	"github.com/farhan-shahid/exchangerates/boc"
+	"github.com/farhan-shahid/exchangerates/boe"
+	"github.com/farhan-shahid/exchangerates/config"
+	"github.com/farhan-shahid/exchangerates/crossrate"
+	"github.com/farhan-shahid/exchangerates/dateexpr"
 	"github.com/farhan-shahid/exchangerates/ecb"
 	"github.com/farhan-shahid/exchangerates/ecbsql"
+	"github.com/farhan-shahid/exchangerates/fed"
 	"github.com/farhan-shahid/exchangerates/googlefinance"
 	"github.com/farhan-shahid/exchangerates/mock"
 )
@@ -20,52 +27,159 @@ var (
 	_ exchangerates.Store = (*mock.Store)(nil)
 	_ exchangerates.Store = (*googlefinance.Store)(nil)
 	_ exchangerates.Store = (*ecbsql.Store)(nil)
+	_ exchangerates.Store = (*fed.Store)(nil)
+	_ exchangerates.Store = (*boc.Store)(nil)
+	_ exchangerates.Store = (*boe.Store)(nil)
+	_ exchangerates.Store = (*crossrate.Store)(nil)
 )
 
+// lookback is how many days are searched back for the latest fixing when no date is given
+const lookback = 7
+
+// command is a subcommand of exchangerates
+type command struct {
+	name    string
+	args    string // the arguments following the flags
+	summary string
+	run     func(args []string)
+}
+
+var commands []command
+
+func init() {
+	commands = []command{
+		{"rate", "", "print the exchange rate between two currencies", rateMain},
+		{"convert", "<amount> FROM TO", "convert an amount between two currencies", convertMain},
+		{"series", "", "print the exchange rates between two dates", seriesMain},
+		{"matrix", "", "print the exchange rates between every pair of currencies", matrixMain},
+		{"chart", "", "save a chart of the exchange rates of a month", chartMain},
+		{"currencies", "", "list the currencies quoted by a store", currenciesMain},
+		{"stores", "", "list the configured stores", storesMain},
+		{"sync", "[store ...]", "download the datasets of stores and report what they hold", syncMain},
+		{"shell", "", "read commands from a prompt, loading the store once", shellMain},
+	}
+}
+
 func main() {
-	var (
-		storename = flag.String("store", "ecbsql", "the store to be used")
-		from      = flag.String("from", "EUR", "the currency to convert from")
-		to        = flag.String("to", "USD", "the currency to convert to")
-		date      = flag.String("date", "2017-03-02", "the date for which to get exchange rate")
-		getchart  = flag.Bool("getchart", false, "set to true to get exchange rate chart")
-		month     = flag.Int("month", 1, "the month for which to get exchange rate chart")
-		year      = flag.Int("year", 2017, "the year for which to get exchange rate chart")
-	)
-	flag.Parse()
-
-	var s exchangerates.Store
-	if *storename == "ecbsql" {
-		s = ecbsql.New()
-	} else if *storename == "ecb" {
-		s = ecb.New()
-	} else if *storename == "googlefinance" {
-		s = googlefinance.New()
-	} else {
-		log.Fatal("Invalid store")
-	}
-
-	if !*getchart {
-		val, err := s.GetExchangeRate(*from, *to, *date)
-		if err != nil {
-			log.Fatal(err)
-		}
-		fmt.Println(val)
-	} else {
-		rates, err := s.GetMonthExchangeRates(*from, *to, *year, *month)
-		if err != nil {
-			log.Fatal(err)
-		}
-		filename := "chart.png"
-		file, err := os.Create(filename)
-		if err != nil {
-			log.Fatal(err)
+	log.SetFlags(0)
+	log.SetPrefix("exchangerates: ")
+
+	if len(os.Args) < 2 {
+		usage()
+		os.Exit(exitUsage)
+	}
+	name := os.Args[1]
+	if name == "help" || name == "-h" || name == "-help" || name == "--help" {
+		usage()
+		return
+	}
+	for _, cmd := range commands {
+		if cmd.name == name {
+			cmd.run(os.Args[2:])
+			return
 		}
-		defer file.Close()
-		err = chart.MakeRateChart(*from, *to, rates, file)
-		if err != nil {
-			log.Fatal(err)
+	}
+	if strings.HasPrefix(name, "-") {
+		// flags without a command, such as -from EUR -to USD, are those of rate before commands existed
+		fmt.Fprintln(os.Stderr, "exchangerates: running without a command is deprecated, use 'exchangerates rate' instead")
+		rateMain(os.Args[1:])
+		return
+	}
+	fmt.Fprintf(os.Stderr, "exchangerates: unknown command %q\n\n", name)
+	usage()
+	os.Exit(exitUsage)
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: exchangerates <command> [flags] [arguments]")
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "commands:")
+	for _, cmd := range commands {
+		fmt.Fprintf(os.Stderr, "  %-12s%s\n", cmd.name, cmd.summary)
+	}
+	fmt.Fprintln(os.Stderr)
+	fmt.Fprintln(os.Stderr, "Run 'exchangerates <command> -help' for the flags of a command.")
+}
+
+// newFlagSet returns the flags of the named command, with a usage message describing it
+func newFlagSet(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	fs.Var(&output, "output", "the `format` results are printed in: text, json, csv or tsv")
+	fs.Usage = func() {
+		for _, cmd := range commands {
+			if cmd.name == name {
+				fmt.Fprintf(os.Stderr, "usage: exchangerates %s\n\n%s\n\nflags:\n", strings.TrimSpace(name+" [flags] "+cmd.args), cmd.summary)
+			}
 		}
-		fmt.Println(filename + " has been saved to current directory")
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// storeFlags are the flags selecting the store a command reads exchange rates from
+type storeFlags struct {
+	config *string
+	store  *string
+	pivots *string
+}
+
+func addStoreFlags(fs *flag.FlagSet) storeFlags {
+	return storeFlags{
+		config: addConfigFlag(fs),
+		store:  fs.String("store", "", "the store to be used, the configured default store (ecb unless configured) when empty"),
+		pivots: fs.String("pivots", "", "comma separated pivot currencies preferred by the cross store, overriding the configured ones"),
+	}
+}
+
+func addConfigFlag(fs *flag.FlagSet) *string {
+	return fs.String("config", os.Getenv("EXCHANGERATES_CONFIG"), "a JSON, YAML or TOML configuration file, overridden by EXCHANGERATES_* environment variables")
+}
+
+// open returns the selected store along with its name. Stores that failed to load their dataset are
+// reported, so that lookups in them do not fail as if the rates requested did not exist
+func (f storeFlags) open() (string, exchangerates.Store, error) {
+	set := make(map[string]string)
+	if *f.pivots != "" {
+		set["stores.cross.pivots"] = *f.pivots
+	}
+	cfg, err := config.Load(*f.config, set)
+	if err != nil {
+		return "", nil, err
+	}
+	name := *f.store
+	if name == "" {
+		name = cfg.DefaultStore
+	}
+	s, err := cfg.OpenStore(name)
+	if err != nil {
+		return "", nil, usageError{err}
+	}
+	if sr, ok := s.(exchangerates.StatusReporter); ok && sr.Status().Err != nil {
+		return "", nil, upstreamError{fmt.Errorf("loading %s failed: %v", name, sr.Status().Err)}
+	}
+	return name, s, nil
+}
+
+// resolveDate resolves the date expression given by a -date flag, if any, to the day it names, the last one of
+// periods, and reports whether it names a period. It exits when the expression is incorrect and is called
+// before stores are opened
+func resolveDate(value string) (string, bool) {
+	if value == "" {
+		return "", false
+	}
+	r, err := parseDate("date", value)
+	if err != nil {
+		fatal(err)
+	}
+	return r.End.Format("2006-01-02"), r.Period
+}
+
+// parseDate resolves a date expression such as 2017-03-02, yesterday, -3d or 2017-Q1, relative to the current
+// day in UTC
+func parseDate(name, value string) (dateexpr.Range, error) {
+	r, err := dateexpr.Parse(value, time.Now().UTC())
+	if err != nil {
+		return r, usagef("incorrect %s %q, should be similar to 2016-03-28, yesterday, -3d, 2017-03 or 2017-Q1", name, value)
 	}
+	return r, nil
 }
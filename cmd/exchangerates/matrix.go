@@ -0,0 +1,38 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates/crossrate"
+)
+
+// matrixMain prints an aligned table of the exchange rates between every pair of the given currencies
+func matrixMain(args []string) {
+	fs := newFlagSet("matrix")
+	var (
+		store      = addStoreFlags(fs)
+		currencies = fs.String("currencies", "USD,EUR,GBP,JPY", "comma separated currencies to include in the matrix")
+		date       = fs.String("date", "", "the date for which to get exchange rates such as 2017-03-02, yesterday or -3d, the latest fixing when empty")
+	)
+	fs.Parse(args)
+
+	currs := strings.Split(*currencies, ",")
+	if len(currs) < 2 {
+		fatal(usagef("at least two currencies are required"))
+	}
+	day, period := resolveDate(*date)
+
+	name, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+	res, err := lookupRate(s, currs[0], currs[1], day, period)
+	if err != nil {
+		fatal(err)
+	}
+	rates, source, err := crossrate.MatrixSource(s, currs, res.Date)
+	if err != nil {
+		fatal(upstream(err))
+	}
+	show(&matrixJSON{Store: name, Date: res.Date, Currencies: currs, Rates: rates, Source: source})
+}
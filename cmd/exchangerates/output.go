@@ -0,0 +1,416 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/crossrate"
+)
+
+// Exit codes of the commands
+const (
+	exitFailure  = 1 // an unexpected error, such as an incorrect configuration or a file that cannot be written
+	exitUsage    = 2 // incorrect flags or arguments, as exited with by the flag package
+	exitNotFound = 3 // no exchange rate is held for the currencies or dates requested
+	exitUpstream = 4 // a store could not load its data or answer, such as when its source is unreachable
+)
+
+// usageError reports incorrect flags or arguments
+type usageError struct {
+	error
+}
+
+// usagef returns a usageError formatted like fmt.Errorf
+func usagef(format string, a ...interface{}) error {
+	return usageError{fmt.Errorf(format, a...)}
+}
+
+// upstreamError reports a store that failed to load its data or to answer
+type upstreamError struct {
+	error
+}
+
+// upstream returns the error of a store as an upstreamError, unless it is nil or reports exchange rates
+// the store does not hold
+func upstream(err error) error {
+	if err == nil || exchangerates.IsNotFound(err) {
+		return err
+	}
+	return upstreamError{err}
+}
+
+// fatal prints err and exits with the code matching its kind
+func fatal(err error) {
+	log.Print(err)
+	switch err.(type) {
+	case usageError:
+		os.Exit(exitUsage)
+	case upstreamError:
+		os.Exit(exitUpstream)
+	}
+	if exchangerates.IsNotFound(err) {
+		os.Exit(exitNotFound)
+	}
+	os.Exit(exitFailure)
+}
+
+// outputFormat is the format results are printed in, one of text, json, csv or tsv.
+// It is set by the -output flag every command has
+type outputFormat string
+
+var output = outputFormat("text")
+
+func (f *outputFormat) String() string {
+	return string(*f)
+}
+
+func (f *outputFormat) Set(value string) error {
+	switch value {
+	case "text", "json", "csv", "tsv":
+		*f = outputFormat(value)
+		return nil
+	}
+	return errors.New("should be one of text, json, csv or tsv")
+}
+
+// result is the output of a command. It is printed as JSON by encoding it, with the field names of the
+// server's JSON responses, and as CSV or TSV records whose header holds the same names
+type result interface {
+	header() []string
+	records() [][]string
+	text(w io.Writer) error
+}
+
+// show prints r to stdout in the output format
+func show(r result) {
+	var err error
+	switch output {
+	case "json":
+		err = json.NewEncoder(os.Stdout).Encode(r)
+	case "csv", "tsv":
+		w := csv.NewWriter(os.Stdout)
+		if output == "tsv" {
+			w.Comma = '\t'
+		}
+		w.Write(r.header())
+		w.WriteAll(r.records())
+		err = w.Error()
+	default:
+		err = r.text(os.Stdout)
+	}
+	if err != nil {
+		fatal(err)
+	}
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
+
+// rateJSON is an exchange rate along with the query it answers, as returned by the server
+type rateJSON struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Date  string   `json:"date"`
+	Store string   `json:"store"`
+	Rate  float64  `json:"rate"`
+	Path  []string `json:"path,omitempty"`
+}
+
+func (r *rateJSON) header() []string {
+	return []string{"from", "to", "date", "store", "rate", "path"}
+}
+
+func (r *rateJSON) records() [][]string {
+	return [][]string{{r.From, r.To, r.Date, r.Store, formatRate(r.Rate), strings.Join(r.Path, " ")}}
+}
+
+func (r *rateJSON) text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, r.Rate)
+	if len(r.Path) > 0 {
+		fmt.Fprintln(os.Stderr, "path:", strings.Join(r.Path, " -> "))
+	}
+	return err
+}
+
+// conversionJSON is an amount converted at an exchange rate
+type conversionJSON struct {
+	rateJSON
+	Amount    float64 `json:"amount"`
+	Converted float64 `json:"converted"`
+}
+
+func (c *conversionJSON) header() []string {
+	return append(c.rateJSON.header(), "amount", "converted")
+}
+
+func (c *conversionJSON) records() [][]string {
+	records := c.rateJSON.records()
+	records[0] = append(records[0], formatRate(c.Amount), formatRate(c.Converted))
+	return records
+}
+
+func (c *conversionJSON) text(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "%s %s = %s %s (%s)\n", formatRate(c.Amount), c.From, strconv.FormatFloat(c.Converted, 'f', 2, 64), c.To, c.Date)
+	return err
+}
+
+type dateRateJSON struct {
+	Date string  `json:"date"`
+	Rate float64 `json:"rate"`
+}
+
+// dateRates is a series of exchange rates
+type dateRates []dateRateJSON
+
+func toDateRates(rates []exchangerates.DateRate) dateRates {
+	res := make(dateRates, 0, len(rates))
+	for _, r := range rates {
+		res = append(res, dateRateJSON{Date: r.Date.Format("2006-01-02"), Rate: r.Rate})
+	}
+	return res
+}
+
+func (d dateRates) header() []string {
+	return []string{"date", "rate"}
+}
+
+func (d dateRates) records() [][]string {
+	records := make([][]string, 0, len(d))
+	for _, r := range d {
+		records = append(records, []string{r.Date, formatRate(r.Rate)})
+	}
+	return records
+}
+
+func (d dateRates) text(w io.Writer) error {
+	for _, r := range d {
+		if _, err := fmt.Fprintln(w, r.Date, r.Rate); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+type ohlcJSON struct {
+	Date  string  `json:"date"`
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
+}
+
+// bars is a series of open, high, low and close rates
+type bars []ohlcJSON
+
+func (b bars) header() []string {
+	return []string{"date", "open", "high", "low", "close"}
+}
+
+func (b bars) records() [][]string {
+	records := make([][]string, 0, len(b))
+	for _, bar := range b {
+		records = append(records, []string{bar.Date, formatRate(bar.Open), formatRate(bar.High), formatRate(bar.Low), formatRate(bar.Close)})
+	}
+	return records
+}
+
+func (b bars) text(w io.Writer) error {
+	for _, bar := range b {
+		if _, err := fmt.Fprintln(w, bar.Date, bar.Open, bar.High, bar.Low, bar.Close); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// matrixJSON holds the exchange rates between every pair of currencies, as returned by the server
+type matrixJSON struct {
+	Store      string      `json:"store"`
+	Date       string      `json:"date"`
+	Currencies []string    `json:"currencies"`
+	Rates      [][]float64 `json:"rates"`
+	Source     string      `json:"source"`
+}
+
+func (m *matrixJSON) header() []string {
+	return append([]string{""}, m.Currencies...)
+}
+
+func (m *matrixJSON) records() [][]string {
+	records := make([][]string, 0, len(m.Rates))
+	for i, row := range m.Rates {
+		record := []string{m.Currencies[i]}
+		for _, rate := range row {
+			record = append(record, formatRate(rate))
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+func (m *matrixJSON) text(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "\t"+strings.Join(m.Currencies, "\t")+"\t")
+	for i, row := range m.Rates {
+		fmt.Fprint(tw, m.Currencies[i]+"\t")
+		for _, rate := range row {
+			fmt.Fprint(tw, strconv.FormatFloat(rate, 'f', 5, 64)+"\t")
+		}
+		fmt.Fprintln(tw)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	if m.Source == crossrate.FromRates {
+		_, err := fmt.Fprintln(w, "derived from exchange rates rounded to 5 decimal places")
+		return err
+	}
+	return nil
+}
+
+// chartJSON describes a saved chart
+type chartJSON struct {
+	File   string `json:"file"`
+	Format string `json:"format"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Store  string `json:"store"`
+	Start  string `json:"start"`
+	End    string `json:"end"`
+	Points int    `json:"points"`
+}
+
+func (c *chartJSON) header() []string {
+	return []string{"file", "format", "from", "to", "store", "start", "end", "points"}
+}
+
+func (c *chartJSON) records() [][]string {
+	return [][]string{{c.File, c.Format, c.From, c.To, c.Store, c.Start, c.End, strconv.Itoa(c.Points)}}
+}
+
+func (c *chartJSON) text(w io.Writer) error {
+	_, err := fmt.Fprintln(w, c.File+" has been saved")
+	return err
+}
+
+// currencies lists currency codes
+type currencies []string
+
+func (c currencies) header() []string {
+	return []string{"currency"}
+}
+
+func (c currencies) records() [][]string {
+	records := make([][]string, 0, len(c))
+	for _, curr := range c {
+		records = append(records, []string{curr})
+	}
+	return records
+}
+
+func (c currencies) text(w io.Writer) error {
+	for _, curr := range c {
+		if _, err := fmt.Fprintln(w, curr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// storeJSON describes a configured store
+type storeJSON struct {
+	Name    string `json:"name"`
+	Enabled bool   `json:"enabled"`
+	Default bool   `json:"default"`
+}
+
+type storeList []storeJSON
+
+func (l storeList) header() []string {
+	return []string{"name", "enabled", "default"}
+}
+
+func (l storeList) records() [][]string {
+	records := make([][]string, 0, len(l))
+	for _, s := range l {
+		records = append(records, []string{s.Name, strconv.FormatBool(s.Enabled), strconv.FormatBool(s.Default)})
+	}
+	return records
+}
+
+func (l storeList) text(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	for _, s := range l {
+		status := "disabled"
+		if s.Enabled {
+			status = "enabled"
+		}
+		if s.Default {
+			status += ", default"
+		}
+		fmt.Fprintf(tw, "%s\t%s\n", s.Name, status)
+	}
+	return tw.Flush()
+}
+
+// storeStatusJSON describes the dataset held by a store, as returned by the server's /status
+type storeStatusJSON struct {
+	Name    string     `json:"name"`
+	Updated *time.Time `json:"updated,omitempty"`
+	Latest  string     `json:"latest,omitempty"`
+	Rows    int        `json:"rows"`
+	Error   string     `json:"error,omitempty"`
+}
+
+func toStoreStatus(name string, status exchangerates.Status) storeStatusJSON {
+	res := storeStatusJSON{Name: name, Latest: status.Latest, Rows: status.Rows}
+	if !status.Updated.IsZero() {
+		res.Updated = &status.Updated
+	}
+	if status.Err != nil {
+		res.Error = status.Err.Error()
+	}
+	return res
+}
+
+type storeStatuses []storeStatusJSON
+
+func (l storeStatuses) header() []string {
+	return []string{"name", "updated", "latest", "rows", "error"}
+}
+
+func (l storeStatuses) records() [][]string {
+	records := make([][]string, 0, len(l))
+	for _, s := range l {
+		updated := ""
+		if s.Updated != nil {
+			updated = s.Updated.UTC().Format(time.RFC3339)
+		}
+		records = append(records, []string{s.Name, updated, s.Latest, strconv.Itoa(s.Rows), s.Error})
+	}
+	return records
+}
+
+func (l storeStatuses) text(w io.Writer) error {
+	for _, s := range l {
+		if s.Error != "" {
+			fmt.Fprintf(os.Stderr, "%s: %s\n", s.Name, s.Error)
+			continue
+		}
+		if _, err := fmt.Fprintf(w, "%s: %d days up to %s\n", s.Name, s.Rows, s.Latest); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// rateMain prints the exchange rate between two currencies
+func rateMain(args []string) {
+	fs := newFlagSet("rate")
+	var (
+		store = addStoreFlags(fs)
+		from  = fs.String("from", "EUR", "the currency to convert from")
+		to    = fs.String("to", "USD", "the currency to convert to")
+		date  = fs.String("date", "", "the date for which to get the exchange rate such as 2017-03-02, yesterday or -3d, the latest fixing when empty")
+	)
+	fs.Parse(args)
+	day, period := resolveDate(*date)
+
+	name, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+	res, err := lookupRate(s, *from, *to, day, period)
+	if err != nil {
+		fatal(err)
+	}
+	res.Store = name
+	show(res)
+}
+
+// convertMain prints an amount converted between two currencies
+func convertMain(args []string) {
+	fs := newFlagSet("convert")
+	var (
+		store = addStoreFlags(fs)
+		date  = fs.String("date", "", "the date of the exchange rate to convert with such as 2017-03-02, yesterday or -3d, the latest fixing when empty")
+	)
+	fs.Parse(args)
+	if fs.NArg() != 3 {
+		fs.Usage()
+		fatal(usagef("expected an amount and two currencies, got %d arguments", fs.NArg()))
+	}
+	amount, err := strconv.ParseFloat(fs.Arg(0), 64)
+	if err != nil {
+		fatal(usagef("incorrect amount %q", fs.Arg(0)))
+	}
+	day, period := resolveDate(*date)
+
+	name, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+	res, err := lookupRate(s, strings.ToUpper(fs.Arg(1)), strings.ToUpper(fs.Arg(2)), day, period)
+	if err != nil {
+		fatal(err)
+	}
+	res.Store = name
+	show(&conversionJSON{rateJSON: *res, Amount: amount, Converted: amount * res.Rate})
+}
+
+// lookupRate returns the exchange rate on date, or the latest one when date is empty or the last day of a
+// period, which may not be a business day, along with the currencies it was triangulated through
+func lookupRate(s exchangerates.Store, from, to, date string, period bool) (*rateJSON, error) {
+	var err error
+	if date == "" || period {
+		end := time.Now()
+		if date != "" {
+			end, _ = time.Parse("2006-01-02", date)
+		}
+		date, _, err = exchangerates.GetLatestExchangeRate(s, from, to, end, lookback)
+		if err != nil {
+			return nil, upstream(err)
+		}
+	}
+
+	res := &rateJSON{From: from, To: to, Date: date}
+	if ps, ok := s.(exchangerates.PathStore); ok {
+		res.Rate, res.Path, err = ps.GetExchangeRatePath(from, to, date)
+	} else {
+		res.Rate, err = s.GetExchangeRate(from, to, date)
+	}
+	if err != nil {
+		return nil, upstream(err)
+	}
+	return res, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/series"
+)
+
+// seriesMain prints the exchange rates between two dates, optionally filled and resampled into periods
+func seriesMain(args []string) {
+	fs := newFlagSet("series")
+	var (
+		store  = addStoreFlags(fs)
+		from   = fs.String("from", "EUR", "the currency to convert from")
+		to     = fs.String("to", "USD", "the currency to convert to")
+		start  = fs.String("start", "", "the first date of the series such as 2017-03-02, -1m or 2017-Q1, 30 days before the end when empty")
+		end    = fs.String("end", "", "the last date of the series such as 2017-03-02, yesterday or 2017-Q1, today when empty")
+		period = fs.String("period", "daily", "the period to resample into: daily, weekly, monthly, quarterly or yearly")
+		method = fs.String("method", "last", "the resampling method: last, end, avg or ohlc")
+		fill   = fs.String("fill", "", "forward fill missing rates over days or weekdays")
+	)
+	fs.Parse(args)
+
+	p, err := series.ParsePeriod(*period)
+	if err != nil {
+		fatal(usageError{err})
+	}
+	if *fill != "" && *fill != "days" && *fill != "weekdays" {
+		fatal(usagef("fill should be one of days or weekdays"))
+	}
+	endDate := time.Now().UTC().Truncate(24 * time.Hour)
+	if *end != "" {
+		r, err := parseDate("end", *end)
+		if err != nil {
+			fatal(err)
+		}
+		endDate = r.End
+	}
+	startDate := endDate.AddDate(0, 0, -30)
+	if *start != "" {
+		r, err := parseDate("start", *start)
+		if err != nil {
+			fatal(err)
+		}
+		startDate = r.Start
+	}
+
+	if endDate.Before(startDate) {
+		fatal(usagef("the end date is before the start date"))
+	}
+
+	_, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+
+	rates, err := exchangerates.GetRangeExchangeRates(s, *from, *to, startDate, endDate)
+	if err != nil {
+		fatal(upstream(err))
+	}
+
+	if *fill != "" {
+		rates = series.FillForward(rates, series.Days(startDate, endDate, *fill == "weekdays"))
+	}
+
+	if *method == "ohlc" {
+		res := make(bars, 0)
+		for _, b := range series.Bars(rates, p) {
+			res = append(res, ohlcJSON{Date: b.Date.Format("2006-01-02"), Open: b.Open, High: b.High, Low: b.Low, Close: b.Close})
+		}
+		show(res)
+		return
+	}
+
+	rates, err = series.Resample(rates, p, *method)
+	if err != nil {
+		fatal(usageError{err})
+	}
+	show(toDateRates(rates))
+}
@@ -0,0 +1,284 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/chart"
+	"github.com/farhan-shahid/exchangerates/config"
+)
+
+const shellHelp = `commands:
+  100 USD in JPY [on 2017-03-02]    convert an amount, at the latest fixing unless a date is given
+  USD/JPY [on yesterday]            print an exchange rate, also typed as USD in JPY
+  chart GBP/EUR [2016-06] [file]    save a chart of a period, the current month unless given
+  currencies [-3d]                  list the currencies quoted by the store
+  store [name]                      print the store used, or switch to another one
+  help                              print this help
+  quit                              leave the shell, as does Ctrl-D
+
+dates may also be given as today, last business day, -2w, end of last month or 2017-Q1,
+followed by a time zone such as Asia/Tokyo or UTC+9
+`
+
+// shellCommands are the words starting the commands of the shell, completed with tab
+var shellCommands = []string{"chart", "currencies", "help", "quit", "store"}
+
+// errQuit is returned by the quit command
+var errQuit = errors.New("quit")
+
+// shell answers the commands typed at its prompt from stores loaded once
+type shell struct {
+	flags  storeFlags
+	name   string
+	store  exchangerates.Store
+	stores map[string]exchangerates.Store
+
+	currs currencies // the currencies of the store, once listed for completion
+}
+
+// shellMain reads commands from an interactive prompt, so that the dataset of a store is loaded once for
+// all the exchange rates looked up
+func shellMain(args []string) {
+	fs := newFlagSet("shell")
+	var (
+		store   = addStoreFlags(fs)
+		history = fs.String("history", defaultHistoryFile(), "the file commands are saved to and recalled from, none when empty")
+	)
+	fs.Parse(args)
+
+	name, s, err := store.open()
+	if err != nil {
+		fatal(err)
+	}
+	sh := &shell{flags: store, name: name, store: s, stores: map[string]exchangerates.Store{name: s}}
+
+	lr := newLineReader(os.Stdin, os.Stdout, *history)
+	lr.complete = sh.complete
+	if lr.interactive {
+		fmt.Println(`Type "help" for the commands. Tab completes currencies and arrows recall previous commands.`)
+	}
+	for {
+		lr.prompt = sh.name + "> "
+		line, err := lr.readLine()
+		if err == io.EOF {
+			return
+		}
+		if err != nil {
+			fatal(err)
+		}
+		if err = sh.exec(line); err == errQuit {
+			return
+		} else if err != nil {
+			log.Print(err)
+		}
+	}
+}
+
+// defaultHistoryFile returns ~/.exchangerates_history, or no file when the home directory is unknown
+func defaultHistoryFile() string {
+	home := os.Getenv("HOME")
+	if home == "" {
+		return ""
+	}
+	return filepath.Join(home, ".exchangerates_history")
+}
+
+// exec runs a command typed at the prompt
+func (sh *shell) exec(line string) error {
+	fields := strings.Fields(line)
+	if len(fields) == 0 {
+		return nil
+	}
+
+	switch strings.ToLower(fields[0]) {
+	case "help", "?":
+		fmt.Print(shellHelp)
+		return nil
+	case "quit", "exit":
+		return errQuit
+	case "store":
+		return sh.switchStore(fields[1:])
+	case "currencies":
+		return sh.currencies(fields[1:])
+	case "chart":
+		return sh.chart(fields[1:])
+	}
+	return sh.convert(fields)
+}
+
+// convert prints an exchange rate, or an amount converted at it, when typed as
+// [amount] FROM/TO [on date] or [amount] FROM in TO [on date], the date being any date expression
+func (sh *shell) convert(fields []string) error {
+	line := strings.Join(fields, " ")
+	amount, err := strconv.ParseFloat(fields[0], 64)
+	hasAmount := err == nil
+	if hasAmount {
+		fields = fields[1:]
+	}
+
+	date, period := "", false
+	for i, f := range fields {
+		if strings.EqualFold(f, "on") {
+			r, err := parseDate("date", strings.Join(fields[i+1:], " "))
+			if err != nil {
+				return err
+			}
+			date, period, fields = r.End.Format("2006-01-02"), r.Period, fields[:i]
+			break
+		}
+	}
+
+	var from, to string
+	switch {
+	case len(fields) == 1 && strings.Count(fields[0], "/") == 1:
+		pair := strings.Split(fields[0], "/")
+		from, to = pair[0], pair[1]
+	case len(fields) == 3 && (strings.EqualFold(fields[1], "in") || strings.EqualFold(fields[1], "to")):
+		from, to = fields[0], fields[2]
+	default:
+		return usagef(`unknown command %q, type "help" for the commands`, line)
+	}
+
+	res, err := lookupRate(sh.store, strings.ToUpper(from), strings.ToUpper(to), date, period)
+	if err != nil {
+		return err
+	}
+	res.Store = sh.name
+	if hasAmount {
+		show(&conversionJSON{rateJSON: *res, Amount: amount, Converted: amount * res.Rate})
+	} else {
+		show(res)
+	}
+	return nil
+}
+
+// chart saves a chart, when typed as chart FROM/TO [dates] [file], the dates being a period such as 2016-06,
+// 2017-Q1 or last month, the current month unless given. The format is that of the file extension, png unless
+// it is .svg or .gif
+func (sh *shell) chart(args []string) error {
+	if len(args) == 0 || strings.Count(args[0], "/") != 1 {
+		return usagef("expected chart FROM/TO [dates] [file]")
+	}
+	pair := strings.Split(strings.ToUpper(args[0]), "/")
+	args = args[1:]
+	filename := ""
+	if n := len(args); n > 0 {
+		switch strings.ToLower(filepath.Ext(args[n-1])) {
+		case ".png", ".svg", ".gif":
+			filename, args = args[n-1], args[:n-1]
+		}
+	}
+	period := "this month"
+	if len(args) > 0 {
+		period = strings.Join(args, " ")
+	}
+	r, err := parseDate("dates", period)
+	if err != nil {
+		return err
+	}
+	if filename == "" {
+		label := r.Start.Format("2006-01")
+		if r.Start.Year() != r.End.Year() || r.Start.Month() != r.End.Month() {
+			label = r.Start.Format("2006-01-02") + "_" + r.End.Format("2006-01-02")
+		}
+		filename = fmt.Sprintf("%s-%s-%s.png", pair[0], pair[1], label)
+	}
+	format, _ := chartFormat("", filename)
+
+	res, err := writeChart(sh.store, pair[0], pair[1], r.Start, r.End, filename, chart.Options{Format: format})
+	if err != nil {
+		return err
+	}
+	res.Store = sh.name
+	show(res)
+	return nil
+}
+
+// currencies lists the currencies quoted by the store, when typed as currencies [date]
+func (sh *shell) currencies(args []string) error {
+	date, period := "", false
+	if len(args) > 0 {
+		r, err := parseDate("date", strings.Join(args, " "))
+		if err != nil {
+			return err
+		}
+		date, period = r.End.Format("2006-01-02"), r.Period
+	}
+	currs, err := quotedCurrencies(sh.store, sh.name, date, period)
+	if err != nil {
+		return err
+	}
+	show(currs)
+	return nil
+}
+
+// switchStore prints the store used, or opens the named store and uses it from then on, when typed as store [name]
+func (sh *shell) switchStore(args []string) error {
+	if len(args) == 0 {
+		fmt.Println(sh.name)
+		return nil
+	}
+	name := args[0]
+	s, ok := sh.stores[name]
+	if !ok {
+		*sh.flags.store = name
+		var err error
+		if _, s, err = sh.flags.open(); err != nil {
+			return err
+		}
+		sh.stores[name] = s
+	}
+	sh.name, sh.store, sh.currs = name, s, nil
+	return nil
+}
+
+// complete returns the commands, store names or currencies that may replace the word before pos
+func (sh *shell) complete(line []rune, pos int) (int, []string) {
+	start := pos
+	for start > 0 && line[start-1] != ' ' && line[start-1] != '/' {
+		start--
+	}
+	word := string(line[start:pos])
+	before := strings.Fields(string(line[:start]))
+
+	var candidates []string
+	switch {
+	case len(before) == 1 && strings.EqualFold(before[0], "store"):
+		candidates = config.StoreNames
+	case len(before) == 0:
+		candidates = append(append([]string(nil), shellCommands...), sh.completions()...)
+		word = strings.ToLower(word)
+	default:
+		candidates = sh.completions()
+	}
+
+	var words []string
+	for _, c := range candidates {
+		if strings.HasPrefix(c, word) || strings.HasPrefix(c, strings.ToUpper(word)) {
+			words = append(words, c)
+		}
+	}
+	sort.Strings(words)
+	return start, words
+}
+
+// completions returns the currencies quoted by the store, listing them on first use
+func (sh *shell) completions() []string {
+	if sh.currs == nil {
+		currs, err := quotedCurrencies(sh.store, sh.name, "", false)
+		if err != nil {
+			return nil
+		}
+		sh.currs = currs
+	}
+	return sh.currs
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"errors"
+	"os"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/config"
+)
+
+// errNoDataset is returned when syncing a store answering lookups without a dataset of its own
+var errNoDataset = errors.New("the store does not hold a dataset")
+
+// syncMain loads the datasets of the named stores, or of all enabled stores holding one, and reports what
+// they hold. Stores keeping their dataset in a database, which is not downloaded when they are opened, are refreshed
+func syncMain(args []string) {
+	fs := newFlagSet("sync")
+	cfgFile := addConfigFlag(fs)
+	fs.Parse(args)
+
+	cfg, err := config.Load(*cfgFile, nil)
+	if err != nil {
+		fatal(err)
+	}
+	names, all := fs.Args(), fs.NArg() == 0
+	if all {
+		names = cfg.Enabled()
+	}
+
+	res := make(storeStatuses, 0, len(names))
+	failed := false
+	for _, name := range names {
+		s, err := cfg.OpenStore(name)
+		if err != nil {
+			fatal(usageError{err})
+		}
+		status, err := syncStore(s)
+		if err == errNoDataset && all {
+			continue
+		}
+		if err != nil {
+			status.Err = err
+		}
+		failed = failed || status.Err != nil
+		res = append(res, toStoreStatus(name, status))
+	}
+	show(res)
+	if failed {
+		os.Exit(exitUpstream)
+	}
+}
+
+// syncStore refreshes a store unless its dataset was just downloaded when it was opened
+func syncStore(s exchangerates.Store) (exchangerates.Status, error) {
+	sr, ok := s.(exchangerates.StatusReporter)
+	if !ok {
+		return exchangerates.Status{}, errNoDataset
+	}
+	if r, ok := s.(exchangerates.Refresher); ok && sr.Status().Updated.IsZero() {
+		if err := r.Refresh(); err != nil {
+			return exchangerates.Status{}, err
+		}
+	}
+	return sr.Status(), nil
+}
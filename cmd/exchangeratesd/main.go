@@ -1,24 +1,254 @@
 package main
 
 import (
+	"context"
+	"crypto/tls"
 	"flag"
 	"log"
 	"net/http"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/alert"
+	"github.com/farhan-shahid/exchangerates/auth"
+	"github.com/farhan-shahid/exchangerates/config"
+	"github.com/farhan-shahid/exchangerates/refresh"
 	"github.com/farhan-shahid/exchangerates/server"
+	_ "github.com/go-sql-driver/mysql" //register driver
+	"github.com/jmoiron/sqlx"
 )
 
+// flagKeys maps the flags to the configuration keys they override
+var flagKeys = map[string]string{
+	"addr":             "server.addr",
+	"refresh":          "refresh.interval",
+	"alerts":           "server.alerts",
+	"keys":             "auth.keys",
+	"keys-dsn":         "auth.dsn",
+	"log-format":       "log.format",
+	"trust-proxy":      "log.trust_proxy",
+	"ready":            "server.ready",
+	"max-age":          "server.max_age",
+	"tls-cert":         "server.tls_cert",
+	"tls-key":          "server.tls_key",
+	"read-timeout":     "server.read_timeout",
+	"write-timeout":    "server.write_timeout",
+	"idle-timeout":     "server.idle_timeout",
+	"shutdown-timeout": "server.shutdown_timeout",
+}
+
 func main() {
-	addr := flag.String("addr", "localhost:7777", "the address of the server")
+	d := config.Default()
+	configFile := flag.String("config", os.Getenv("EXCHANGERATES_CONFIG"), "a JSON, YAML or TOML configuration file, overridden by EXCHANGERATES_* environment variables and flags")
+	flag.String("addr", d.Server.Addr, "the address of the server")
+	flag.Duration("refresh", d.Refresh.Interval, "how often store datasets are refreshed, 0 disables refreshing of stores without their own interval")
+	flag.String("alerts", d.Server.Alerts, "the file alert rules and their history are kept in")
+	flag.String("keys", d.Auth.Keys, "a JSON file of the API keys allowed to make requests, keys are not required when empty")
+	flag.String("keys-dsn", d.Auth.DSN, "the DSN of a MySQL database holding the allowed API keys in its APIKey table")
+	flag.String("log-format", d.Log.Format, "the format of the access log written to stdout, json or combined")
+	flag.Bool("trust-proxy", d.Log.TrustProxy, "log the client address given by the X-Forwarded-For header of a proxy")
+	flag.String("ready", strings.Join(d.Server.Ready, ","), "comma separated stores that must have loaded their data for /readyz to succeed")
+	flag.Duration("max-age", d.Server.MaxAge, "the age after which a store's data is considered stale by /readyz, 0 disables the check")
+	flag.String("tls-cert", d.Server.TLSCert, "the PEM certificate file to serve HTTPS with, reloaded when it changes")
+	flag.String("tls-key", d.Server.TLSKey, "the PEM key file of the certificate")
+	flag.Duration("read-timeout", d.Server.ReadTimeout, "the maximum duration for reading a request")
+	flag.Duration("write-timeout", d.Server.WriteTimeout, "the maximum duration for writing a response, streams are exempt")
+	flag.Duration("idle-timeout", d.Server.IdleTimeout, "how long idle keep-alive connections are kept open")
+	flag.Duration("shutdown-timeout", d.Server.ShutdownTimeout, "how long in-flight requests are given to finish on shutdown")
 	flag.Parse()
 
+	set := make(map[string]string)
+	flag.Visit(func(f *flag.Flag) {
+		if key, ok := flagKeys[f.Name]; ok {
+			set[key] = f.Value.String()
+		}
+	})
+	cfg, err := config.Load(*configFile, set)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	format, err := server.ParseLogFormat(cfg.Log.Format)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	stores, err := cfg.OpenStores()
+	if err != nil {
+		log.Fatal(err)
+	}
+	server.SetStores(stores, cfg.DefaultStore)
+	server.SetCacheMaxAge(cfg.Cache.HistoricMaxAge, cfg.Cache.CurrentMaxAge)
+
 	s := server.New()
-	s.AddLogging(os.Stdout)
+	s.SetAccessLog(os.Stdout, format, cfg.Log.TrustProxy)
+	s.SetReadiness(cfg.Server.Ready, cfg.Server.MaxAge)
+	s.SetStreamOrigins(cfg.Server.Origins)
+
+	var keyring *auth.Manager
+	if cfg.Auth.Keys != "" || cfg.Auth.DSN != "" {
+		source := auth.File(cfg.Auth.Keys)
+		if cfg.Auth.DSN != "" {
+			db, err := sqlx.Connect("mysql", cfg.Auth.DSN)
+			if err != nil {
+				log.Fatal(err)
+			}
+			source = auth.SQL(db)
+		}
+		keyring, err = auth.New(source)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s.SetAuth(keyring)
+		s.SetMetricsAuth(cfg.Auth.Metrics)
+	}
+
+	refresher := refresh.New(cfg.Refresh.Interval)
+	derived := schedule(refresher, cfg)
+	refresher.OnRefresh(func(store string, err error) {
+		if err != nil {
+			log.Printf("refreshing %s failed: %v", store, err)
+		}
+	})
+
+	m, err := alert.New(cfg.Server.Alerts, server.LookupStore)
+	if err != nil {
+		log.Fatal(err)
+	}
+	s.SetAlerts(m)
+	refresher.OnRefresh(func(store string, err error) {
+		if err != nil {
+			return
+		}
+		m.EvaluateStore(store)
+		for _, name := range derived {
+			m.EvaluateStore(name)
+		}
+	})
+	refresher.OnRefresh(func(store string, err error) {
+		if err != nil {
+			return
+		}
+		s.PublishStore(store)
+		for _, name := range derived {
+			s.PublishStore(name)
+		}
+	})
+	refresher.Start()
+
 	srv := &http.Server{
-		Addr:    *addr,
-		Handler: s,
+		Addr:              cfg.Server.Addr,
+		Handler:           s,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		ReadHeaderTimeout: cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+	}
+
+	var certs *server.CertReloader
+	if cfg.Server.TLSCert != "" {
+		certs, err = server.NewCertReloader(cfg.Server.TLSCert, cfg.Server.TLSKey)
+		if err != nil {
+			log.Fatal(err)
+		}
+		srv.TLSConfig = &tls.Config{GetCertificate: certs.GetCertificate}
+	}
+
+	errs := make(chan error, 1)
+	go func() {
+		log.Printf("Serving on %s", srv.Addr)
+		if certs != nil {
+			errs <- srv.ListenAndServeTLS("", "")
+		} else {
+			errs <- srv.ListenAndServe()
+		}
+	}()
+
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, syscall.SIGTERM, syscall.SIGINT, syscall.SIGHUP)
+	for {
+		select {
+		case err := <-errs:
+			log.Fatal(err)
+		case sig := <-signals:
+			if sig == syscall.SIGHUP {
+				reload(*configFile, set, s, refresher, keyring, certs)
+				continue
+			}
+
+			log.Printf("received %v, shutting down", sig)
+			ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
+			s.CloseStreams()
+			err := srv.Shutdown(ctx)
+			cancel()
+			refresher.Stop()
+			m.Wait()
+			if err != nil {
+				log.Fatalf("shutting down: %v", err)
+			}
+			return
+		}
+	}
+}
+
+// schedule registers the stores that refresh their dataset with the refresher, at their own interval
+// when configured with one, and returns the names of the other stores
+func schedule(refresher *refresh.Scheduler, cfg *config.Config) (derived []string) {
+	for _, name := range server.StoreNames() {
+		store, _ := server.LookupStore(name)
+		r, ok := exchangerates.Unwrap(store).(exchangerates.Refresher)
+		if !ok {
+			derived = append(derived, name)
+			continue
+		}
+		if interval := cfg.Stores[name].Refresh; interval > 0 {
+			refresher.AddEvery(name, r, interval)
+		} else {
+			refresher.Add(name, r)
+		}
+	}
+	return derived
+}
+
+// reload reads the configuration, the API keys and the TLS certificate again, keeping the current ones
+// when that fails. Of the configuration, the settings that can change while serving are applied: cache
+// lifetimes, readiness checks, the log format and refresh intervals. Other settings need a restart
+func reload(configFile string, set map[string]string, s *server.Server, refresher *refresh.Scheduler, keyring *auth.Manager, certs *server.CertReloader) {
+	ok := true
+	failed := func(what string, err error) {
+		log.Printf("reloading %s failed: %v", what, err)
+		ok = false
+	}
+
+	cfg, err := config.Load(configFile, set)
+	if err != nil {
+		failed("configuration", err)
+	} else if format, err := server.ParseLogFormat(cfg.Log.Format); err != nil {
+		failed("configuration", err)
+	} else {
+		server.SetCacheMaxAge(cfg.Cache.HistoricMaxAge, cfg.Cache.CurrentMaxAge)
+		s.SetReadiness(cfg.Server.Ready, cfg.Server.MaxAge)
+		s.SetLogFormat(format)
+
+		refresher.Stop()
+		refresher.Interval = cfg.Refresh.Interval
+		schedule(refresher, cfg)
+		refresher.Start()
+	}
+	if keyring != nil {
+		if err := keyring.Reload(); err != nil {
+			failed("API keys", err)
+		}
+	}
+	if certs != nil {
+		if err := certs.Reload(); err != nil {
+			failed("TLS certificate", err)
+		}
+	}
+	if ok {
+		log.Println("reloaded configuration")
 	}
-	log.Printf("Serving on %s", srv.Addr)
-	log.Fatal(srv.ListenAndServe())
 }
@@ -0,0 +1,272 @@
+// Package config loads the configuration shared by exchangerates and exchangeratesd from a JSON,
+// YAML or TOML file, overridden by EXCHANGERATES_* environment variables
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// EnvPrefix starts the names of the environment variables overriding configuration keys, which are
+// the upper cased keys with dots replaced by underscores, such as EXCHANGERATES_SERVER_ADDR
+const EnvPrefix = "EXCHANGERATES_"
+
+// StoreNames are the stores that can be configured
+var StoreNames = []string{"ecb", "ecbsql", "googlefinance", "fed", "boc", "boe", "cross", "mock"}
+
+// Config holds the settings of both commands, keyed in files by the names in the config tags
+type Config struct {
+	DefaultStore string           `config:"default_store"`
+	Stores       map[string]Store `config:"stores"`
+	Server       Server           `config:"server"`
+	Cache        Cache            `config:"cache"`
+	Auth         Auth             `config:"auth"`
+	Refresh      Refresh          `config:"refresh"`
+	Log          Log              `config:"log"`
+}
+
+// Store configures one of the StoreNames
+type Store struct {
+	Enabled bool          `config:"enabled"`
+	Refresh time.Duration `config:"refresh"` // overrides refresh.interval when not 0
+	DSN     string        `config:"dsn"`     // ecbsql only, MYSQLPASS is used for the root user when empty
+	Pivots  []string      `config:"pivots"`  // cross only
+	Sources []string      `config:"sources"` // cross only, the stores quotes are triangulated over
+}
+
+// Server configures the HTTP server of exchangeratesd
+type Server struct {
+	Addr            string        `config:"addr"`
+	TLSCert         string        `config:"tls_cert"`
+	TLSKey          string        `config:"tls_key"`
+	ReadTimeout     time.Duration `config:"read_timeout"`
+	WriteTimeout    time.Duration `config:"write_timeout"`
+	IdleTimeout     time.Duration `config:"idle_timeout"`
+	ShutdownTimeout time.Duration `config:"shutdown_timeout"`
+	Ready           []string      `config:"ready"`
+	MaxAge          time.Duration `config:"max_age"`
+	Alerts          string        `config:"alerts"`
+	Origins         []string      `config:"origins"` // of the web pages allowed to open WebSocket streams
+}
+
+// Cache configures the Cache-Control lifetimes of responses
+type Cache struct {
+	HistoricMaxAge time.Duration `config:"historic_max_age"`
+	CurrentMaxAge  time.Duration `config:"current_max_age"`
+}
+
+// Auth configures where API keys are read from, keys are not required when both are empty
+type Auth struct {
+	Keys    string `config:"keys"`
+	DSN     string `config:"dsn"`
+	Metrics bool   `config:"metrics"` // require a key for /metrics too
+}
+
+// Refresh configures how often store datasets are reloaded
+type Refresh struct {
+	Interval time.Duration `config:"interval"`
+}
+
+// Log configures the access log
+type Log struct {
+	Format     string `config:"format"`
+	TrustProxy bool   `config:"trust_proxy"`
+}
+
+// Error reports a configuration key that could not be used
+type Error struct {
+	Key string
+	Err error
+}
+
+func (e *Error) Error() string {
+	return e.Key + ": " + e.Err.Error()
+}
+
+// Default returns the configuration used when no file is given. Every store is enabled but mock, which
+// only serves tests, and ecbsql, which needs a database: it is enabled when the MYSQLPASS environment
+// variable holds the password of the local server's root user, or when it is given a DSN
+func Default() *Config {
+	c := &Config{
+		DefaultStore: "ecb",
+		Stores:       make(map[string]Store, len(StoreNames)),
+		Server: Server{
+			Addr:            "localhost:7777",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    time.Minute,
+			IdleTimeout:     2 * time.Minute,
+			ShutdownTimeout: 30 * time.Second,
+			Ready:           []string{"ecb"},
+			MaxAge:          6 * time.Hour,
+			Alerts:          "alerts.json",
+		},
+		Cache: Cache{
+			HistoricMaxAge: 365 * 24 * time.Hour,
+			CurrentMaxAge:  5 * time.Minute,
+		},
+		Refresh: Refresh{Interval: time.Hour},
+		Log:     Log{Format: "json"},
+	}
+	for _, name := range StoreNames {
+		c.Stores[name] = Store{Enabled: true}
+	}
+	c.Stores["ecbsql"] = Store{Enabled: os.Getenv("MYSQLPASS") != ""}
+	c.Stores["cross"] = Store{Enabled: true, Pivots: []string{"USD", "EUR"}, Sources: []string{"ecb", "fed", "boc", "boe"}}
+	c.Stores["mock"] = Store{}
+	return c
+}
+
+// Load returns the default configuration overridden by the file at path, if not empty, then by the
+// environment and last by the set keys, such as those given by command line flags.
+// The format of the file is given by its extension: .json, .yaml, .yml or .toml
+func Load(path string, set map[string]string) (*Config, error) {
+	c := Default()
+	if path != "" {
+		data, err := ioutil.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		if err := c.Parse(data, strings.TrimPrefix(filepath.Ext(path), ".")); err != nil {
+			return nil, fmt.Errorf("%s: %v", path, err)
+		}
+	}
+	if err := c.Env(os.Environ()); err != nil {
+		return nil, err
+	}
+	for key, value := range set {
+		if err := c.Set(key, value); err != nil {
+			return nil, err
+		}
+	}
+	if err := c.Validate(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Parse overrides the configuration with the keys of a json, yaml or toml document
+func (c *Config) Parse(data []byte, format string) error {
+	var (
+		tree map[string]interface{}
+		err  error
+	)
+	switch format {
+	case "json":
+		tree, err = parseJSON(data)
+	case "yaml", "yml":
+		tree, err = parseYAML(data)
+	case "toml":
+		tree, err = parseTOML(data)
+	default:
+		return errors.New("unknown config format " + format + ", should be json, yaml or toml")
+	}
+	if err != nil {
+		return err
+	}
+	return decode(c, tree)
+}
+
+// Set overrides a single key, such as server.addr, with a value in the form used by the environment
+func (c *Config) Set(key, value string) error {
+	return decodeKey(c, key, value)
+}
+
+// Env overrides the configuration with the EXCHANGERATES_* variables of environ, given as KEY=value
+func (c *Config) Env(environ []string) error {
+	vars := envKeys()
+	for _, kv := range environ {
+		i := strings.Index(kv, "=")
+		if i < 0 || !strings.HasPrefix(kv, EnvPrefix) {
+			continue
+		}
+		key, ok := vars[kv[:i]]
+		if !ok {
+			continue
+		}
+		if err := c.Set(key, kv[i+1:]); err != nil {
+			return fmt.Errorf("%s: %v", kv[:i], err)
+		}
+	}
+	return nil
+}
+
+// Validate checks that the settings are consistent with each other
+func (c *Config) Validate() error {
+	for _, name := range StoreNames {
+		store := c.Stores[name]
+		key := "stores." + name
+		if store.Refresh < 0 {
+			return &Error{key + ".refresh", errors.New("should not be negative")}
+		}
+		if store.DSN != "" && name != "ecbsql" {
+			return &Error{key + ".dsn", errors.New("only applies to ecbsql")}
+		}
+		if name != "cross" && (len(store.Pivots) > 0 || len(store.Sources) > 0) {
+			return &Error{key, errors.New("pivots and sources only apply to cross")}
+		}
+	}
+
+	if cross := c.Stores["cross"]; cross.Enabled {
+		if len(cross.Sources) == 0 {
+			return &Error{"stores.cross.sources", errors.New("at least one source is required")}
+		}
+		for _, src := range cross.Sources {
+			switch {
+			case src == "cross" || src == "googlefinance":
+				return &Error{"stores.cross.sources", errors.New(src + " does not provide quotes")}
+			case !c.enabled(src):
+				return &Error{"stores.cross.sources", errors.New(src + " is not an enabled store")}
+			}
+		}
+	}
+
+	if !c.enabled(c.DefaultStore) {
+		return &Error{"default_store", errors.New(quote(c.DefaultStore) + " is not an enabled store")}
+	}
+	for _, name := range c.Server.Ready {
+		if !c.enabled(name) {
+			return &Error{"server.ready", errors.New(quote(name) + " is not an enabled store")}
+		}
+	}
+	if c.Server.Addr == "" {
+		return &Error{"server.addr", errors.New("is required")}
+	}
+	if (c.Server.TLSCert == "") != (c.Server.TLSKey == "") {
+		return &Error{"server.tls_key", errors.New("server.tls_cert and server.tls_key should be given together")}
+	}
+	if c.Refresh.Interval < 0 {
+		return &Error{"refresh.interval", errors.New("should not be negative")}
+	}
+	if c.Auth.Keys != "" && c.Auth.DSN != "" {
+		return &Error{"auth.dsn", errors.New("only one of auth.keys and auth.dsn should be given")}
+	}
+	if c.Log.Format != "json" && c.Log.Format != "combined" {
+		return &Error{"log.format", errors.New(quote(c.Log.Format) + " should be json or combined")}
+	}
+	return nil
+}
+
+// Enabled returns the names of the enabled stores in the order of StoreNames
+func (c *Config) Enabled() []string {
+	var names []string
+	for _, name := range StoreNames {
+		if c.enabled(name) {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+func (c *Config) enabled(name string) bool {
+	store, ok := c.Stores[name]
+	return ok && store.Enabled
+}
+
+func quote(s string) string {
+	return `"` + s + `"`
+}
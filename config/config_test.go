@@ -0,0 +1,237 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+var documents = map[string]string{
+	"json": `{
+  "default_store": "fed",
+  "stores": {
+    "ecbsql": {"enabled": false},
+    "fed": {"refresh": "30m"},
+    "cross": {"pivots": ["USD"], "sources": ["fed", "boe"]}
+  },
+  "server": {"addr": ":8080", "ready": ["fed", "boe"], "read_timeout": "5s"},
+  "auth": {"keys": "keys.json"},
+  "log": {"format": "combined", "trust_proxy": true}
+}`,
+	"yaml": `
+# exchangeratesd configuration
+default_store: fed
+stores:
+  ecbsql: {enabled: false}
+  fed:
+    refresh: 30m   # the fed publishes weekly
+  cross:
+    pivots: [USD]
+    sources:
+    - fed
+    - 'boe'
+server:
+  addr: ":8080"
+  ready:
+    - fed
+    - boe
+  read_timeout: 5s
+auth:
+  keys: keys.json
+log:
+  format: combined
+  trust_proxy: true
+`,
+	"toml": `
+# exchangeratesd configuration
+default_store = "fed"
+
+[stores.ecbsql]
+enabled = false
+
+[stores.fed]
+refresh = "30m" # the fed publishes weekly
+
+[stores.cross]
+pivots = ["USD"]
+sources = ["fed", 'boe']
+
+[server]
+addr = ":8080"
+ready = ["fed", "boe"]
+read_timeout = "5s"
+
+[auth]
+keys = "keys.json"
+
+[log]
+format = "combined"
+trust_proxy = true
+`,
+}
+
+func TestParse(t *testing.T) {
+	expected := Default()
+	expected.DefaultStore = "fed"
+	expected.Stores["ecbsql"] = Store{}
+	expected.Stores["fed"] = Store{Enabled: true, Refresh: 30 * time.Minute}
+	expected.Stores["cross"] = Store{Enabled: true, Pivots: []string{"USD"}, Sources: []string{"fed", "boe"}}
+	expected.Server.Addr = ":8080"
+	expected.Server.Ready = []string{"fed", "boe"}
+	expected.Server.ReadTimeout = 5 * time.Second
+	expected.Auth.Keys = "keys.json"
+	expected.Log = Log{Format: "combined", TrustProxy: true}
+
+	for format, doc := range documents {
+		c := Default()
+		if err := c.Parse([]byte(doc), format); err != nil {
+			t.Errorf("%s: %v", format, err)
+			continue
+		}
+		if err := c.Validate(); err != nil {
+			t.Errorf("%s: %v", format, err)
+		}
+		if !reflect.DeepEqual(c, expected) {
+			t.Errorf("%s: expected %+v, got %+v", format, expected, c)
+		}
+	}
+}
+
+func TestErrors(t *testing.T) {
+	var tests = []struct {
+		format        string
+		doc           string
+		ExpectedError string
+	}{
+		{"json", `{"server": {"adr": ":80"}}`, "server.adr: unknown key"},
+		{"json", `{"stores": {"ecbsql": {"dsn": 5}}}`, "stores.ecbsql.dsn: should be a string"},
+		{"json", `{"server": {"ready": ["ecb", 1]}}`, "server.ready[1]: should be a string"},
+		{"json", "{\n\"server\": {,}}", "line 2: invalid character ',' looking for beginning of object key string"},
+		{"yaml", "stores:\n  ecbsq:\n    enabled: false", "stores.ecbsq: unknown store, should be one of ecb, ecbsql, googlefinance, fed, boc, boe, cross, mock"},
+		{"yaml", "stores:\n  ecb:\n    enabled: 'yes'", `stores.ecb.enabled: "yes" is not true or false`},
+		{"yaml", "server:\n  read_timeout: 15", "server.read_timeout: should be a duration such as 1h30m"},
+		{"yaml", "server:\n  addr: a\n    port: 80", "yaml: line 3: mapping values are not allowed in this context"},
+		{"yaml", "log: json\nlog: combined", "yaml: unmarshal errors:\n  line 2: key \"log\" already set in map"},
+		{"yaml", "log:\n  format json", "log: should be a table of keys"},
+		{"yaml", "- ecb", "the document should be a mapping of keys"},
+		{"toml", "[server]\naddr = localhost", `Near line 2 (last key parsed 'server.addr'): expected value but found "localhost" instead`},
+		{"toml", "[refresh]\ninterval = 60", "refresh.interval: should be a duration such as 1h30m"},
+		{"toml", "log = \"json\"", "log: should be a table of keys"},
+		{"toml", "[[stores]]", "stores: should be a table of stores"},
+		{"xml", "<config/>", "unknown config format xml, should be json, yaml or toml"},
+	}
+
+	for _, test := range tests {
+		err := Default().Parse([]byte(test.doc), test.format)
+		if err == nil || err.Error() != test.ExpectedError {
+			t.Errorf("%s %q: expected error=%q, got %v", test.format, test.doc, test.ExpectedError, err)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	var tests = []struct {
+		key, value    string
+		ExpectedError string
+	}{
+		{"default_store", "ecbsql2", `default_store: "ecbsql2" is not an enabled store`},
+		{"stores.ecb.enabled", "false", `stores.cross.sources: ecb is not an enabled store`},
+		{"stores.cross.sources", "ecb,googlefinance", `stores.cross.sources: googlefinance does not provide quotes`},
+		{"stores.fed.dsn", "root@/db", `stores.fed.dsn: only applies to ecbsql`},
+		{"server.ready", "ecb,boj", `server.ready: "boj" is not an enabled store`},
+		{"server.tls_cert", "cert.pem", `server.tls_key: server.tls_cert and server.tls_key should be given together`},
+		{"refresh.interval", "-1h", `refresh.interval: should not be negative`},
+		{"log.format", "common", `log.format: "common" should be json or combined`},
+	}
+
+	for _, test := range tests {
+		c := Default()
+		if err := c.Set(test.key, test.value); err != nil {
+			t.Fatal(err)
+		}
+		err := c.Validate()
+		if err == nil || err.Error() != test.ExpectedError {
+			t.Errorf("%s=%s: expected error=%q, got %v", test.key, test.value, test.ExpectedError, err)
+		}
+	}
+}
+
+func TestDefault(t *testing.T) {
+	defer os.Setenv("MYSQLPASS", os.Getenv("MYSQLPASS"))
+
+	os.Setenv("MYSQLPASS", "")
+	c := Default()
+	if c.Stores["ecbsql"].Enabled || c.Stores["mock"].Enabled {
+		t.Errorf("expected ecbsql and mock to be disabled, got %v", c.Enabled())
+	}
+	if err := c.Parse([]byte(`{"stores": {"ecbsql": {"dsn": "root@/db", "enabled": false}}}`), "json"); err != nil || c.Stores["ecbsql"].Enabled {
+		t.Errorf("expected ecbsql to stay disabled along with its DSN, got %+v (%v)", c.Stores["ecbsql"], err)
+	}
+	if err := c.Parse([]byte(`{"stores": {"ecbsql": {"dsn": "root@/db"}}}`), "json"); err != nil || !c.Stores["ecbsql"].Enabled {
+		t.Errorf("expected ecbsql to be enabled by its DSN, got %+v (%v)", c.Stores["ecbsql"], err)
+	}
+
+	os.Setenv("MYSQLPASS", "secret")
+	if c := Default(); !c.Stores["ecbsql"].Enabled {
+		t.Errorf("expected ecbsql to be enabled with MYSQLPASS, got %v", c.Enabled())
+	}
+}
+
+func TestEnv(t *testing.T) {
+	c := Default()
+	err := c.Env([]string{
+		"EXCHANGERATES_SERVER_ADDR=:9090",
+		"EXCHANGERATES_STORES_ECBSQL_DSN=rates:secret@tcp(db:3306)/ExchangeDB",
+		"EXCHANGERATES_STORES_CROSS_PIVOTS=GBP, EUR",
+		"EXCHANGERATES_LOG_TRUST_PROXY=1",
+		"EXCHANGERATES_UNKNOWN=1",
+		"HOME=/root",
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.Server.Addr != ":9090" || c.Stores["ecbsql"].DSN != "rates:secret@tcp(db:3306)/ExchangeDB" || !c.Stores["ecbsql"].Enabled ||
+		!reflect.DeepEqual(c.Stores["cross"].Pivots, []string{"GBP", "EUR"}) || !c.Log.TrustProxy {
+		t.Errorf("environment variables were not applied: %+v", c)
+	}
+
+	err = c.Env([]string{"EXCHANGERATES_SERVER_MAX_AGE=6"})
+	if expected := `EXCHANGERATES_SERVER_MAX_AGE: server.max_age: "6" is not a duration such as 1h30m`; err == nil || err.Error() != expected {
+		t.Errorf("expected error=%q, got %v", expected, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	dir, err := ioutil.TempDir("", "config")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "exchangerates.yml")
+	if err := ioutil.WriteFile(path, []byte(documents["yaml"]), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c, err := Load(path, map[string]string{"server.addr": ":7070"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DefaultStore != "fed" || c.Server.Addr != ":7070" {
+		t.Errorf("expected the file to be overridden by the keys set, got %+v", c)
+	}
+	if enabled := c.Enabled(); !reflect.DeepEqual(enabled, []string{"ecb", "googlefinance", "fed", "boc", "boe", "cross"}) {
+		t.Errorf("unexpected enabled stores %v", enabled)
+	}
+
+	if _, err := Load(path, map[string]string{"default_store": "ecbsql"}); err == nil || err.Error() != `default_store: "ecbsql" is not an enabled store` {
+		t.Errorf("expected the configuration to be validated, got %v", err)
+	}
+
+	ioutil.WriteFile(path, []byte("server:\n  addr: [a"), 0600)
+	if _, err := Load(path, nil); err == nil || err.Error() != path+": yaml: line 2: did not find expected ',' or ']'" {
+		t.Errorf("expected a parse error naming the file, got %v", err)
+	}
+}
@@ -0,0 +1,262 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v2"
+)
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// decode overrides the fields of c with the values of a parsed document. Values are strings, bools,
+// numbers, []interface{} or map[string]interface{}, strings being converted to the type of the field
+// so that environment variables can set any key
+func decode(c *Config, tree map[string]interface{}) error {
+	if err := decodeValue(reflect.ValueOf(c).Elem(), tree, ""); err != nil {
+		return err
+	}
+
+	// a DSN is given to use ecbsql, unless it is disabled along with it
+	stores, _ := tree["stores"].(map[string]interface{})
+	ecbsql, _ := stores["ecbsql"].(map[string]interface{})
+	if _, ok := ecbsql["dsn"]; ok {
+		if _, ok := ecbsql["enabled"]; !ok {
+			s := c.Stores["ecbsql"]
+			s.Enabled = s.DSN != ""
+			c.Stores["ecbsql"] = s
+		}
+	}
+	return nil
+}
+
+// decodeKey overrides the dotted key with a value given as a string
+func decodeKey(c *Config, key, value string) error {
+	parts := strings.Split(key, ".")
+	var tree interface{} = value
+	for i := len(parts) - 1; i >= 0; i-- {
+		tree = map[string]interface{}{parts[i]: tree}
+	}
+	return decode(c, tree.(map[string]interface{}))
+}
+
+func decodeValue(v reflect.Value, value interface{}, key string) error {
+	if v.Type() == durationType {
+		s, ok := value.(string)
+		if !ok {
+			return &Error{key, errors.New("should be a duration such as 1h30m")}
+		}
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return &Error{key, errors.New(quote(s) + " is not a duration such as 1h30m")}
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+
+	switch v.Kind() {
+	case reflect.Struct:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return &Error{key, errors.New("should be a table of keys")}
+		}
+		for _, k := range sortedKeys(m) {
+			f, ok := field(v, k)
+			if !ok {
+				return &Error{join(key, k), errors.New("unknown key")}
+			}
+			if err := decodeValue(f, m[k], join(key, k)); err != nil {
+				return err
+			}
+		}
+	case reflect.Map:
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return &Error{key, errors.New("should be a table of stores")}
+		}
+		if v.IsNil() {
+			v.Set(reflect.MakeMap(v.Type()))
+		}
+		for _, k := range sortedKeys(m) {
+			if !knownStore(k) {
+				return &Error{join(key, k), errors.New("unknown store, should be one of " + strings.Join(StoreNames, ", "))}
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if existing := v.MapIndex(reflect.ValueOf(k)); existing.IsValid() {
+				elem.Set(existing)
+			}
+			if err := decodeValue(elem, m[k], join(key, k)); err != nil {
+				return err
+			}
+			v.SetMapIndex(reflect.ValueOf(k), elem)
+		}
+	case reflect.Slice:
+		var items []string
+		switch x := value.(type) {
+		case string:
+			// comma separated, as given by flags and environment variables
+			for _, item := range strings.Split(x, ",") {
+				if item = strings.TrimSpace(item); item != "" {
+					items = append(items, item)
+				}
+			}
+		case []interface{}:
+			for i, item := range x {
+				s, ok := item.(string)
+				if !ok {
+					return &Error{key + "[" + strconv.Itoa(i) + "]", errors.New("should be a string")}
+				}
+				items = append(items, s)
+			}
+		default:
+			return &Error{key, errors.New("should be a list of strings")}
+		}
+		v.Set(reflect.ValueOf(items))
+	case reflect.String:
+		s, ok := value.(string)
+		if !ok {
+			return &Error{key, errors.New("should be a string")}
+		}
+		v.SetString(s)
+	case reflect.Bool:
+		switch x := value.(type) {
+		case bool:
+			v.SetBool(x)
+		case string:
+			b, err := strconv.ParseBool(x)
+			if err != nil {
+				return &Error{key, errors.New(quote(x) + " is not true or false")}
+			}
+			v.SetBool(b)
+		default:
+			return &Error{key, errors.New("should be true or false")}
+		}
+	default:
+		return &Error{key, fmt.Errorf("unsupported type %v", v.Type())}
+	}
+	return nil
+}
+
+// field returns the field of the struct v tagged with key
+func field(v reflect.Value, key string) (reflect.Value, bool) {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		if t.Field(i).Tag.Get("config") == key {
+			return v.Field(i), true
+		}
+	}
+	return reflect.Value{}, false
+}
+
+// envKeys maps the names of the environment variables overriding keys to the keys
+func envKeys() map[string]string {
+	keys := make(map[string]string)
+	var walk func(t reflect.Type, key string)
+	walk = func(t reflect.Type, key string) {
+		switch t.Kind() {
+		case reflect.Struct:
+			for i := 0; i < t.NumField(); i++ {
+				walk(t.Field(i).Type, join(key, t.Field(i).Tag.Get("config")))
+			}
+		case reflect.Map:
+			for _, name := range StoreNames {
+				walk(t.Elem(), join(key, name))
+			}
+		default:
+			keys[EnvPrefix+strings.ToUpper(strings.Replace(key, ".", "_", -1))] = key
+		}
+	}
+	walk(reflect.TypeOf(Config{}), "")
+	return keys
+}
+
+// parseJSON parses a JSON object, reporting the line of syntax errors
+func parseJSON(data []byte) (map[string]interface{}, error) {
+	var tree map[string]interface{}
+	d := json.NewDecoder(bytes.NewReader(data))
+	d.UseNumber()
+	err := d.Decode(&tree)
+	if se, ok := err.(*json.SyntaxError); ok {
+		line := bytes.Count(data[:se.Offset], []byte("\n")) + 1
+		return nil, fmt.Errorf("line %d: %v", line, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+// parseYAML parses a YAML document, whose mappings are keyed by strings in the tree
+func parseYAML(data []byte) (map[string]interface{}, error) {
+	var doc interface{}
+	if err := yaml.UnmarshalStrict(data, &doc); err != nil {
+		return nil, err
+	}
+	if doc == nil {
+		return map[string]interface{}{}, nil
+	}
+	tree, ok := stringKeys(doc).(map[string]interface{})
+	if !ok {
+		return nil, errors.New("the document should be a mapping of keys")
+	}
+	return tree, nil
+}
+
+// stringKeys replaces the map[interface{}]interface{} of YAML mappings by map[string]interface{}
+func stringKeys(value interface{}) interface{} {
+	switch x := value.(type) {
+	case map[interface{}]interface{}:
+		m := make(map[string]interface{}, len(x))
+		for k, v := range x {
+			m[fmt.Sprint(k)] = stringKeys(v)
+		}
+		return m
+	case []interface{}:
+		for i, v := range x {
+			x[i] = stringKeys(v)
+		}
+	}
+	return value
+}
+
+// parseTOML parses a TOML document
+func parseTOML(data []byte) (map[string]interface{}, error) {
+	tree := make(map[string]interface{})
+	if _, err := toml.Decode(string(data), &tree); err != nil {
+		return nil, err
+	}
+	return tree, nil
+}
+
+func knownStore(name string) bool {
+	for _, n := range StoreNames {
+		if n == name {
+			return true
+		}
+	}
+	return false
+}
+
+func join(key, k string) string {
+	if key == "" {
+		return k
+	}
+	return key + "." + k
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"errors"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/boc"
+	"github.com/farhan-shahid/exchangerates/boe"
+	"github.com/farhan-shahid/exchangerates/crossrate"
+	"github.com/farhan-shahid/exchangerates/ecb"
+	"github.com/farhan-shahid/exchangerates/ecbsql"
+	"github.com/farhan-shahid/exchangerates/fed"
+	"github.com/farhan-shahid/exchangerates/googlefinance"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+// OpenStores returns the enabled stores keyed by name, loading their datasets.
+// The cross store triangulates over the same instances of its sources
+func (c *Config) OpenStores() (map[string]exchangerates.Store, error) {
+	stores := make(map[string]exchangerates.Store)
+	for _, name := range c.Enabled() {
+		if _, err := c.open(name, stores); err != nil {
+			return nil, err
+		}
+	}
+	return stores, nil
+}
+
+// OpenStore returns the named store, loading its dataset and those of the stores it depends on
+func (c *Config) OpenStore(name string) (exchangerates.Store, error) {
+	if !c.enabled(name) {
+		return nil, errors.New(name + " is not an enabled store")
+	}
+	return c.open(name, make(map[string]exchangerates.Store))
+}
+
+// open returns the named store, opening it unless it is one of the opened stores
+func (c *Config) open(name string, opened map[string]exchangerates.Store) (exchangerates.Store, error) {
+	if s, ok := opened[name]; ok {
+		return s, nil
+	}
+
+	opts := c.Stores[name]
+	var s exchangerates.Store
+	switch name {
+	case "ecb":
+		s = ecb.New()
+	case "ecbsql":
+		if opts.DSN != "" {
+			s = ecbsql.Open(opts.DSN)
+		} else {
+			s = ecbsql.New()
+		}
+	case "googlefinance":
+		s = googlefinance.New()
+	case "fed":
+		s = fed.New()
+	case "boc":
+		s = boc.New()
+	case "boe":
+		s = boe.New()
+	case "mock":
+		s = mock.New()
+	case "cross":
+		sources := make([]exchangerates.Quoter, 0, len(opts.Sources))
+		for _, src := range opts.Sources {
+			store, err := c.open(src, opened)
+			if err != nil {
+				return nil, err
+			}
+			q, ok := store.(exchangerates.Quoter)
+			if !ok {
+				return nil, &Error{"stores.cross.sources", errors.New(src + " does not provide quotes")}
+			}
+			sources = append(sources, q)
+		}
+		s = crossrate.New(opts.Pivots, sources...)
+	default:
+		return nil, errors.New(name + " is not a valid store")
+	}
+	opened[name] = s
+	return s, nil
+}
@@ -0,0 +1,114 @@
+package crossrate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// DefaultPivots are the pivot currencies preferred when none are configured
+var DefaultPivots = []string{"USD", "EUR"}
+
+// Store computes exchange rates by triangulating over the direct quotes of one or more stores
+type Store struct {
+	Pivots  []string
+	sources []exchangerates.Quoter
+}
+
+// New returns a new instance of Store that combines the quotes of the given sources.
+// Sources added first take precedence when several quote the same pair
+func New(pivots []string, sources ...exchangerates.Quoter) *Store {
+	if len(pivots) == 0 {
+		pivots = DefaultPivots
+	}
+	return &Store{Pivots: pivots, sources: sources}
+}
+
+// WithPivots returns a copy of the Store that prefers the given pivot currencies
+func (s *Store) WithPivots(pivots []string) *Store {
+	return &Store{Pivots: pivots, sources: s.sources}
+}
+
+// GetExchangeRate returns the cross rate between from and to on the date specified
+func (s *Store) GetExchangeRate(from, to string, date string) (float64, error) {
+	rate, _, err := s.GetExchangeRatePath(from, to, date)
+	return rate, err
+}
+
+// GetExchangeRatePath returns the cross rate between from and to on the date specified
+// along with the currencies it was triangulated through
+func (s *Store) GetExchangeRatePath(from, to string, date string) (float64, []string, error) {
+	g, err := s.graph(date)
+	if err != nil {
+		return 0, nil, err
+	}
+	return g.Rate(from, to)
+}
+
+// GetMonthExchangeRates returns a list of exchange rate values for the month specified
+func (s *Store) GetMonthExchangeRates(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+	rates := make([]exchangerates.DateRate, 0, 31)
+	for i := 1; i <= 31; i++ {
+		date := strconv.Itoa(year) + "-" + fmt.Sprintf("%02d", month) + "-" + fmt.Sprintf("%02d", i)
+
+		rate, err := s.GetExchangeRate(from, to, date)
+		if err != nil {
+			continue
+		}
+
+		t, _ := time.Parse("2006-01-02", date)
+		rates = append(rates, exchangerates.DateRate{Rate: rate, Date: t})
+	}
+	if len(rates) == 0 {
+		return nil, exchangerates.NotFound("No data exists")
+	}
+	return rates, nil
+}
+
+// GetQuotes returns the direct quotes of all sources for the date specified
+func (s *Store) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	var (
+		quotes  []exchangerates.Quote
+		lastErr = errors.New("no sources configured")
+	)
+	for _, src := range s.sources {
+		q, err := src.GetQuotes(date)
+		if err != nil {
+			lastErr = err
+			continue
+		}
+		quotes = append(quotes, q...)
+	}
+	if len(quotes) == 0 {
+		return nil, lastErr
+	}
+	return quotes, nil
+}
+
+// Updated returns the time the most recently loaded of the sources was loaded, zero if none of
+// them reports it
+func (s *Store) Updated() time.Time {
+	var updated time.Time
+	for _, source := range s.sources {
+		if u, ok := source.(exchangerates.Updater); ok && u.Updated().After(updated) {
+			updated = u.Updated()
+		}
+	}
+	return updated
+}
+
+func (s *Store) graph(date string) (*Graph, error) {
+	quotes, err := s.GetQuotes(date)
+	if err != nil {
+		return nil, err
+	}
+
+	g := NewGraph(s.Pivots...)
+	for _, q := range quotes {
+		g.Add(q)
+	}
+	return g, nil
+}
@@ -0,0 +1,183 @@
+package crossrate
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+var testQuotes = []exchangerates.Quote{
+	{From: "EUR", To: "USD", Rate: 1.05},
+	{From: "EUR", To: "GBP", Rate: 0.85},
+	{From: "USD", To: "JPY", Rate: 114},
+	{From: "USD", To: "CAD", Rate: 1.34},
+	{From: "GBP", To: "CAD", Rate: 1.64},
+}
+
+func TestGraphRate(t *testing.T) {
+	var tests = []struct {
+		From         string
+		To           string
+		Pivots       []string
+		ExpectedErr  error
+		ExpectedRate float64
+		ExpectedPath []string
+	}{
+		{
+			From:         "EUR",
+			To:           "USD",
+			ExpectedErr:  nil,
+			ExpectedRate: 1.05,
+			ExpectedPath: []string{"EUR", "USD"},
+		},
+		{
+			From:         "USD",
+			To:           "GBP",
+			Pivots:       []string{"EUR"},
+			ExpectedErr:  nil,
+			ExpectedRate: 0.80952,
+			ExpectedPath: []string{"USD", "EUR", "GBP"},
+		},
+		{
+			From:         "JPY",
+			To:           "CAD",
+			ExpectedErr:  nil,
+			ExpectedRate: 0.01175,
+			ExpectedPath: []string{"JPY", "USD", "CAD"},
+		},
+		{
+			From:         "JPY",
+			To:           "GBP",
+			Pivots:       []string{"USD", "EUR"},
+			ExpectedErr:  nil,
+			ExpectedRate: 0.0071,
+			ExpectedPath: []string{"JPY", "USD", "EUR", "GBP"},
+		},
+		{
+			From:         "JPY",
+			To:           "GBP",
+			Pivots:       []string{"CAD"},
+			ExpectedErr:  nil,
+			ExpectedRate: 0.00717,
+			ExpectedPath: []string{"JPY", "USD", "CAD", "GBP"},
+		},
+		{
+			From:         "USD",
+			To:           "USD",
+			ExpectedErr:  nil,
+			ExpectedRate: 1,
+			ExpectedPath: []string{"USD"},
+		},
+		{
+			From:         "USD",
+			To:           "XYZ",
+			ExpectedErr:  exchangerates.NotFound("currency XYZ not found"),
+			ExpectedRate: 0,
+			ExpectedPath: nil,
+		},
+	}
+
+	for i, tt := range tests {
+		g := NewGraph(tt.Pivots...)
+		for _, q := range testQuotes {
+			g.Add(q)
+		}
+
+		got, path, err := g.Rate(tt.From, tt.To)
+		if want, got := tt.ExpectedErr, err; !reflect.DeepEqual(want, got) {
+			t.Fatalf("#%d failed: expected error=%v, got %v", i, want, got)
+		}
+		if want, got := tt.ExpectedRate, got; want != got {
+			t.Fatalf("#%d failed: expected rate=%v, got %v", i, want, got)
+		}
+		if want, got := tt.ExpectedPath, path; !reflect.DeepEqual(want, got) {
+			t.Fatalf("#%d failed: expected path=%v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestStoreGetExchangeRatePath(t *testing.T) {
+	eurStore := mock.New()
+	eurStore.OnGetQuotes = func(date string) ([]exchangerates.Quote, error) {
+		if date != "2017-03-02" {
+			return nil, errors.New("date not found")
+		}
+		return []exchangerates.Quote{{From: "EUR", To: "USD", Rate: 1.05}, {From: "EUR", To: "GBP", Rate: 0.85}}, nil
+	}
+	usdStore := mock.New()
+	usdStore.OnGetQuotes = func(date string) ([]exchangerates.Quote, error) {
+		if date != "2017-03-02" {
+			return nil, errors.New("date not found")
+		}
+		return []exchangerates.Quote{{From: "USD", To: "EUR", Rate: 0.9}, {From: "USD", To: "CAD", Rate: 1.34}}, nil
+	}
+
+	s := New(nil, eurStore, usdStore)
+
+	rate, path, err := s.GetExchangeRatePath("GBP", "CAD", "2017-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.65529, rate; want != got {
+		t.Fatalf("expected rate=%v, got %v", want, got)
+	}
+	if want, got := []string{"GBP", "EUR", "USD", "CAD"}, path; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected path=%v, got %v", want, got)
+	}
+
+	_, err = s.GetExchangeRate("GBP", "CAD", "9999-03-02")
+	if want, got := errors.New("date not found"), err; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected error=%v, got %v", want, got)
+	}
+}
+
+func TestMatrix(t *testing.T) {
+	s := mock.New()
+	s.OnGetQuotes = func(date string) ([]exchangerates.Quote, error) {
+		return testQuotes, nil
+	}
+
+	rates, source, err := MatrixSource(s, []string{"EUR", "USD", "GBP"}, "2017-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if source != FromQuotes {
+		t.Errorf("expected source=%s, got %s", FromQuotes, source)
+	}
+	expected := [][]float64{
+		{1, 1.05, 0.85},
+		{0.95238, 1, 0.80952},
+		{1.17647, 1.23529, 1},
+	}
+	if !reflect.DeepEqual(expected, rates) {
+		t.Fatalf("expected rates=%v, got %v", expected, rates)
+	}
+
+	s.OnGetQuotes = nil
+	s.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return map[string]float64{"EUR": 1, "USD": 1.05}[to], nil
+	}
+	rates, source, err = MatrixSource(s, []string{"EUR", "USD"}, "2017-03-02")
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected = [][]float64{{1, 1.05}, {0.95238, 1}}
+	if !reflect.DeepEqual(expected, rates) {
+		t.Fatalf("expected rates=%v, got %v", expected, rates)
+	}
+	if source != FromRates {
+		t.Errorf("expected source=%s, got %s", FromRates, source)
+	}
+
+	// the reason the quotes were not used is reported along with the failure of the exchange rates
+	s.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 0, exchangerates.NotFound("date not found")
+	}
+	_, err = Matrix(s, []string{"EUR", "USD"}, "2017-03-02")
+	if want := "date not found (quotes: OnGetQuotes not set)"; err == nil || err.Error() != want || !exchangerates.IsNotFound(err) {
+		t.Errorf("expected not found error=%q, got %v", want, err)
+	}
+}
@@ -0,0 +1,130 @@
+package crossrate
+
+import (
+	"sort"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Graph holds direct exchange rate quotes between currencies and computes cross rates through them
+type Graph struct {
+	// Pivots lists the preferred intermediate currencies, most preferred first.
+	// When several paths have the fewest hops, the one going through better ranked pivots is used
+	Pivots []string
+	edges  map[string]map[string]float64
+}
+
+// NewGraph returns an empty Graph using the given preferred pivot currencies
+func NewGraph(pivots ...string) *Graph {
+	return &Graph{Pivots: pivots, edges: make(map[string]map[string]float64)}
+}
+
+// Add adds a direct quote to the graph along with its inverse
+func (g *Graph) Add(q exchangerates.Quote) {
+	if q.Rate == 0 || q.From == q.To {
+		return
+	}
+	g.addEdge(q.From, q.To, q.Rate)
+	g.addEdge(q.To, q.From, 1/q.Rate)
+}
+
+func (g *Graph) addEdge(from, to string, rate float64) {
+	if g.edges[from] == nil {
+		g.edges[from] = make(map[string]float64)
+	}
+	if _, ok := g.edges[from][to]; ok {
+		return // keep the first quote added for a pair
+	}
+	g.edges[from][to] = rate
+}
+
+// Path returns the currencies on the path with the fewest hops between from and to
+func (g *Graph) Path(from, to string) ([]string, error) {
+	if _, ok := g.edges[from]; !ok && from != to {
+		return nil, exchangerates.NotFound("currency " + from + " not found")
+	}
+	if _, ok := g.edges[to]; !ok && from != to {
+		return nil, exchangerates.NotFound("currency " + to + " not found")
+	}
+	if from == to {
+		return []string{from}, nil
+	}
+
+	// breadth first search from the destination gives the hop distance of every currency to it
+	dist := map[string]int{to: 0}
+	queue := []string{to}
+	for len(queue) > 0 {
+		curr := queue[0]
+		queue = queue[1:]
+		for next := range g.edges[curr] {
+			if _, seen := dist[next]; !seen {
+				dist[next] = dist[curr] + 1
+				queue = append(queue, next)
+			}
+		}
+	}
+	if _, ok := dist[from]; !ok {
+		return nil, exchangerates.NotFound("no path from " + from + " to " + to)
+	}
+
+	// walk towards the destination, always stepping to the best ranked currency one hop closer
+	path := []string{from}
+	for curr := from; curr != to; {
+		var candidates []string
+		for next := range g.edges[curr] {
+			if dist[next] == dist[curr]-1 {
+				candidates = append(candidates, next)
+			}
+		}
+		sort.Slice(candidates, func(i, j int) bool {
+			return g.less(candidates[i], candidates[j], to)
+		})
+		curr = candidates[0]
+		path = append(path, curr)
+	}
+	return path, nil
+}
+
+// less reports whether a should be preferred over b as the next currency on a path to dest
+func (g *Graph) less(a, b, dest string) bool {
+	if a == dest || b == dest {
+		return a == dest
+	}
+	ra, rb := g.rank(a), g.rank(b)
+	if ra != rb {
+		return ra < rb
+	}
+	return a < b
+}
+
+func (g *Graph) rank(curr string) int {
+	for i, p := range g.Pivots {
+		if p == curr {
+			return i
+		}
+	}
+	return len(g.Pivots)
+}
+
+// Rate returns the cross rate between from and to along with the path it was computed through
+func (g *Graph) Rate(from, to string) (float64, []string, error) {
+	rate, path, err := g.rate(from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+	return exchangerates.CalcRate(1, rate), path, nil
+}
+
+// rate is like Rate but does not reduce the precision of the result
+func (g *Graph) rate(from, to string) (float64, []string, error) {
+	path, err := g.Path(from, to)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	rate := 1.0
+	for i := 1; i < len(path); i++ {
+		rate *= g.edges[path[i-1]][path[i]]
+	}
+	return rate, path, nil
+}
@@ -0,0 +1,111 @@
+package crossrate
+
+import (
+	"errors"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Sources of the values matrices are computed from
+const (
+	FromQuotes = "quotes" // the direct quotes of the store, at full precision
+	FromRates  = "rates"  // the exchange rates of the store, already rounded to 5 decimal places
+)
+
+// Matrix returns the exchange rates between every pair of currencies on the date specified.
+// Element [i][j] converts one unit of currencies[i] into currencies[j].
+// Each currency is looked up only once against the first one and the pairs are derived from those values,
+// using the store's direct quotes when it is a Quoter
+func Matrix(s exchangerates.Store, currencies []string, date string) ([][]float64, error) {
+	rates, _, err := MatrixSource(s, currencies, date)
+	return rates, err
+}
+
+// MatrixSource is like Matrix but also reports whether the rates were derived FromQuotes or FromRates.
+// The exchange rates of the store, rounded and so less precise, are used when it is not a Quoter or when
+// its quotes do not hold every currency. The error then reports why the quotes could not be used if the
+// exchange rates cannot either
+func MatrixSource(s exchangerates.Store, currencies []string, date string) ([][]float64, string, error) {
+	if len(currencies) == 0 {
+		return nil, "", errors.New("no currencies given")
+	}
+
+	source := FromQuotes
+	values, quoteErr := quoteValues(s, currencies, date)
+	if quoteErr != nil {
+		var err error
+		source = FromRates
+		values, err = rateValues(s, currencies, date)
+		if err != nil {
+			if quoteErr != errNoQuotes {
+				err = wrapError(err, "quotes: "+quoteErr.Error())
+			}
+			return nil, "", err
+		}
+	}
+
+	rates := make([][]float64, len(currencies))
+	for i := range currencies {
+		rates[i] = make([]float64, len(currencies))
+		for j := range currencies {
+			rates[i][j] = exchangerates.CalcRate(values[i], values[j])
+		}
+	}
+	return rates, source, nil
+}
+
+// errNoQuotes is returned by quoteValues for stores that are not Quoters
+var errNoQuotes = errors.New("store does not provide quotes")
+
+// wrapError appends detail to the message of err, keeping it a *NotFoundError when it is one
+func wrapError(err error, detail string) error {
+	msg := err.Error() + " (" + detail + ")"
+	if exchangerates.IsNotFound(err) {
+		return exchangerates.NotFound(msg)
+	}
+	return errors.New(msg)
+}
+
+// quoteValues returns the value of one unit of the first currency in each of the currencies
+// computed from the direct quotes of the store
+func quoteValues(s exchangerates.Store, currencies []string, date string) ([]float64, error) {
+	q, ok := s.(exchangerates.Quoter)
+	if !ok {
+		return nil, errNoQuotes
+	}
+	quotes, err := q.GetQuotes(date)
+	if err != nil {
+		return nil, err
+	}
+
+	pivots := DefaultPivots
+	if cs, ok := exchangerates.Unwrap(s).(*Store); ok {
+		pivots = cs.Pivots
+	}
+	g := NewGraph(pivots...)
+	for _, quote := range quotes {
+		g.Add(quote)
+	}
+
+	values := make([]float64, len(currencies))
+	for i, curr := range currencies {
+		values[i], _, err = g.rate(currencies[0], curr)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// rateValues is like quoteValues but uses the exchange rates returned by the store
+func rateValues(s exchangerates.Store, currencies []string, date string) ([]float64, error) {
+	values := make([]float64, len(currencies))
+	for i, curr := range currencies {
+		value, err := s.GetExchangeRate(currencies[0], curr, date)
+		if err != nil {
+			return nil, err
+		}
+		values[i] = value
+	}
+	return values, nil
+}
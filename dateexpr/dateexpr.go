@@ -0,0 +1,185 @@
+// Package dateexpr resolves the dates given to the server and the command line tool. Besides dates such
+// as 2017-03-02, they may be relative to the current day, such as yesterday or -3d, or name a period,
+// such as 2017-03 or 2017-Q1, and may end with the time zone deciding what the current day is.
+package dateexpr
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Range holds the days named by an expression, from Start to End inclusive, both being midnight UTC as
+// the dates of fixings are. Start and End are the same day for expressions naming a single day.
+// Relative is set when the days depend on the current day, and Period when they are those of a period
+// or its first or last day, which may not be a business day
+type Range struct {
+	Start    time.Time
+	End      time.Time
+	Relative bool
+	Period   bool
+}
+
+var (
+	offsetRe  = regexp.MustCompile(`^-(\d+)([dwmy])$`)
+	quarterRe = regexp.MustCompile(`^(\d{4})-q([1-4])$`)
+	zoneRe    = regexp.MustCompile(`^(?:(?:utc|gmt)([+-]\d{1,2})(?::?(\d{2}))?|([+-]\d{2}):?(\d{2}))$`)
+)
+
+// Parse resolves expr to the days it names, relative to the day of now in its location unless expr ends
+// with a time zone such as Asia/Tokyo, UTC, UTC+9 or -05:00. The expressions understood are
+//
+//	2017-03-02                    a day
+//	today, yesterday              the current day and the one before
+//	last business day             the weekday before the current day
+//	-3d, -2w, -6m, -1y            the day a number of days, weeks, months or years before the current day
+//	2017-03, 2017-Q1, 2017        a month, a quarter or a year
+//	this week, last month, ...    the week, month, quarter or year containing the current day or the one before,
+//	                              the current one ending on the current day
+//	start of P, end of P          the first or last day of the period P, such as end of last month
+func Parse(expr string, now time.Time) (Range, error) {
+	fields := strings.Fields(expr)
+	if n := len(fields); n > 1 {
+		if loc, ok := parseZone(fields[n-1]); ok {
+			now = now.In(loc)
+			fields = fields[:n-1]
+		}
+	}
+	y, m, d := now.Date()
+	today := time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+
+	e := strings.ToLower(strings.Join(fields, " "))
+	switch {
+	case strings.HasPrefix(e, "start of "):
+		r, ok := parsePeriod(strings.TrimPrefix(e, "start of "), today)
+		r.End = r.Start
+		return r, check(expr, ok)
+	case strings.HasPrefix(e, "end of "):
+		r, ok := parsePeriod(strings.TrimPrefix(e, "end of "), today)
+		r.Start = r.End
+		return r, check(expr, ok)
+	}
+
+	switch e {
+	case "today":
+		return day(today, true), nil
+	case "yesterday":
+		return day(today.AddDate(0, 0, -1), true), nil
+	case "last business day":
+		d := today.AddDate(0, 0, -1)
+		for d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			d = d.AddDate(0, 0, -1)
+		}
+		return day(d, true), nil
+	}
+	if t, err := time.Parse("2006-01-02", e); err == nil {
+		return day(t, false), nil
+	}
+	if match := offsetRe.FindStringSubmatch(e); match != nil {
+		n, err := strconv.Atoi(match[1])
+		if err != nil {
+			return Range{}, check(expr, false)
+		}
+		switch match[2] {
+		case "d":
+			return day(today.AddDate(0, 0, -n), true), nil
+		case "w":
+			return day(today.AddDate(0, 0, -7*n), true), nil
+		case "m":
+			return day(today.AddDate(0, -n, 0), true), nil
+		default:
+			return day(today.AddDate(-n, 0, 0), true), nil
+		}
+	}
+
+	r, ok := parsePeriod(e, today)
+	if r.End.After(today) {
+		r.End, r.Relative = today, true
+	}
+	return r, check(expr, ok)
+}
+
+// parsePeriod resolves the week, month, quarter or year named by e, lower case, relative to today
+func parsePeriod(e string, today time.Time) (Range, bool) {
+	if t, err := time.Parse("2006-01", e); err == nil {
+		return months(t, 1, false), true
+	}
+	if match := quarterRe.FindStringSubmatch(e); match != nil {
+		year, _ := strconv.Atoi(match[1])
+		q, _ := strconv.Atoi(match[2])
+		return months(time.Date(year, time.Month(3*q-2), 1, 0, 0, 0, 0, time.UTC), 3, false), true
+	}
+	if t, err := time.Parse("2006", e); err == nil {
+		return months(t, 12, false), true
+	}
+
+	fields := strings.Fields(e)
+	if len(fields) != 2 || fields[0] != "this" && fields[0] != "last" {
+		return Range{}, false
+	}
+	back := 0
+	if fields[0] == "last" {
+		back = 1
+	}
+	switch fields[1] {
+	case "week":
+		monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7-7*back)
+		return Range{Start: monday, End: monday.AddDate(0, 0, 6), Relative: true, Period: true}, true
+	case "month":
+		return months(time.Date(today.Year(), today.Month()-time.Month(back), 1, 0, 0, 0, 0, time.UTC), 1, true), true
+	case "quarter":
+		first := time.Month((int(today.Month())-1)/3*3 + 1)
+		return months(time.Date(today.Year(), first-time.Month(3*back), 1, 0, 0, 0, 0, time.UTC), 3, true), true
+	case "year":
+		return months(time.Date(today.Year()-back, 1, 1, 0, 0, 0, 0, time.UTC), 12, true), true
+	}
+	return Range{}, false
+}
+
+// parseZone returns the location named by s, either an IANA time zone such as Europe/London or an offset
+// from UTC such as UTC+9 or +05:30
+func parseZone(s string) (*time.Location, bool) {
+	switch strings.ToUpper(s) {
+	case "UTC", "GMT", "Z":
+		return time.UTC, true
+	}
+	if match := zoneRe.FindStringSubmatch(strings.ToLower(s)); match != nil {
+		hours, minutes := match[1]+match[3], match[2]+match[4]
+		h, _ := strconv.Atoi(hours)
+		min, _ := strconv.Atoi(minutes)
+		if h < -14 || h > 14 || min > 59 {
+			return nil, false
+		}
+		offset := h*3600 + min*60
+		if strings.HasPrefix(hours, "-") {
+			offset = h*3600 - min*60
+		}
+		return time.FixedZone(fmt.Sprintf("UTC%s:%02d", hours, min), offset), true
+	}
+	if strings.Contains(s, "/") {
+		if loc, err := time.LoadLocation(s); err == nil {
+			return loc, true
+		}
+	}
+	return nil, false
+}
+
+func day(t time.Time, relative bool) Range {
+	return Range{Start: t, End: t, Relative: relative}
+}
+
+// months returns the n months starting with the month of first
+func months(first time.Time, n int, relative bool) Range {
+	first = time.Date(first.Year(), first.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return Range{Start: first, End: first.AddDate(0, n, -1), Relative: relative, Period: true}
+}
+
+// check returns the error reporting expr as incorrect unless ok is set
+func check(expr string, ok bool) error {
+	if ok {
+		return nil
+	}
+	return fmt.Errorf("incorrect date %q, should be similar to 2016-03-28, yesterday, -3d, 2017-03 or 2017-Q1", expr)
+}
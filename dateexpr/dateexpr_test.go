@@ -0,0 +1,68 @@
+package dateexpr
+
+import (
+	"testing"
+	"time"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+func TestParse(t *testing.T) {
+	// a Monday, late enough in the evening UTC to be Tuesday in Tokyo
+	now := time.Date(2017, 3, 6, 20, 0, 0, 0, time.UTC)
+
+	var tests = []struct {
+		Expr             string
+		Start, End       string
+		ExpectedRelative bool
+		ExpectedPeriod   bool
+	}{
+		{"2017-03-02", "2017-03-02", "2017-03-02", false, false},
+		{"today", "2017-03-06", "2017-03-06", true, false},
+		{"Yesterday", "2017-03-05", "2017-03-05", true, false},
+		{"last business day", "2017-03-03", "2017-03-03", true, false},
+		{"-3d", "2017-03-03", "2017-03-03", true, false},
+		{"-2w", "2017-02-20", "2017-02-20", true, false},
+		{"-1m", "2017-02-06", "2017-02-06", true, false},
+		{"-1y", "2016-03-06", "2016-03-06", true, false},
+		{"2017-02", "2017-02-01", "2017-02-28", false, true},
+		{"2016-Q4", "2016-10-01", "2016-12-31", false, true},
+		{"2016", "2016-01-01", "2016-12-31", false, true},
+		{"2017-03", "2017-03-01", "2017-03-06", true, true},
+		{"this week", "2017-03-06", "2017-03-06", true, true},
+		{"last week", "2017-02-27", "2017-03-05", true, true},
+		{"last month", "2017-02-01", "2017-02-28", true, true},
+		{"this quarter", "2017-01-01", "2017-03-06", true, true},
+		{"last quarter", "2016-10-01", "2016-12-31", true, true},
+		{"last year", "2016-01-01", "2016-12-31", true, true},
+		{"end of last month", "2017-02-28", "2017-02-28", true, true},
+		{"start of 2017-Q1", "2017-01-01", "2017-01-01", false, true},
+		{"today Asia/Tokyo", "2017-03-07", "2017-03-07", true, false},
+		{"today UTC+9", "2017-03-07", "2017-03-07", true, false},
+		{"today -05:00", "2017-03-06", "2017-03-06", true, false},
+		{"last business day UTC", "2017-03-03", "2017-03-03", true, false},
+	}
+
+	for _, tt := range tests {
+		r, err := Parse(tt.Expr, now)
+		if err != nil {
+			t.Errorf("%q: %v", tt.Expr, err)
+			continue
+		}
+		if !r.Start.Equal(date(tt.Start)) || !r.End.Equal(date(tt.End)) || r.Relative != tt.ExpectedRelative || r.Period != tt.ExpectedPeriod {
+			t.Errorf("%q: expected %s to %s, relative=%v, period=%v, got %s to %s, relative=%v, period=%v", tt.Expr, tt.Start, tt.End,
+				tt.ExpectedRelative, tt.ExpectedPeriod, r.Start.Format("2006-01-02"), r.End.Format("2006-01-02"), r.Relative, r.Period)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, expr := range []string{"", "2017-3-2", "-3", "+3d", "next month", "2017-Q5", "today Mars/Olympus", "start of today", "UTC"} {
+		if _, err := Parse(expr, time.Now()); err == nil {
+			t.Errorf("%q: expected an error", expr)
+		}
+	}
+}
@@ -0,0 +1,53 @@
+package exchangerates
+
+import (
+	"errors"
+	"time"
+)
+
+// GetRangeExchangeRates returns the exchange rates available between start and end inclusive,
+// fetching them from the store a month at a time. Months the store holds no rates for are skipped,
+// other failures of the store are returned
+func GetRangeExchangeRates(s Store, from, to string, start, end time.Time) ([]DateRate, error) {
+	if end.Before(start) {
+		return nil, errors.New("end date is before start date")
+	}
+
+	var rates []DateRate
+	for month := time.Date(start.Year(), start.Month(), 1, 0, 0, 0, 0, time.UTC); !month.After(end); month = month.AddDate(0, 1, 0) {
+		monthRates, err := s.GetMonthExchangeRates(from, to, month.Year(), int(month.Month()))
+		if IsNotFound(err) {
+			continue
+		}
+		if err != nil {
+			return nil, err
+		}
+		for _, r := range monthRates {
+			if r.Date.Before(start) || r.Date.After(end) {
+				continue
+			}
+			rates = append(rates, r)
+		}
+	}
+	if len(rates) == 0 {
+		return nil, NotFound("No data exists")
+	}
+	return rates, nil
+}
+
+// GetLatestExchangeRate returns the most recent exchange rate available on or before now,
+// looking back at most days days, along with its date. It stops at the first failure of the store other
+// than a missing rate
+func GetLatestExchangeRate(s Store, from, to string, now time.Time, days int) (string, float64, error) {
+	for i := 0; i <= days; i++ {
+		date := now.UTC().AddDate(0, 0, -i).Format("2006-01-02")
+		rate, err := s.GetExchangeRate(from, to, date)
+		if err == nil {
+			return date, rate, nil
+		}
+		if !IsNotFound(err) {
+			return "", 0, err
+		}
+	}
+	return "", 0, NotFound("no recent rates for " + from + "/" + to)
+}
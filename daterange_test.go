@@ -0,0 +1,60 @@
+package exchangerates_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+func TestGetLatestExchangeRate(t *testing.T) {
+	now := time.Date(2017, 3, 6, 0, 0, 0, 0, time.UTC)
+	s := mock.New()
+	s.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		switch {
+		case to == "ERR":
+			return 0, errors.New("connection refused")
+		case date != "2017-03-03":
+			return 0, exchangerates.NotFound("date not found")
+		}
+		return 1.0514, nil
+	}
+
+	date, rate, err := exchangerates.GetLatestExchangeRate(s, "EUR", "USD", now, 7)
+	if err != nil || date != "2017-03-03" || rate != 1.0514 {
+		t.Errorf("expected the rate of 2017-03-03, got %v %v %v", date, rate, err)
+	}
+	if _, _, err = exchangerates.GetLatestExchangeRate(s, "EUR", "USD", now, 2); !exchangerates.IsNotFound(err) {
+		t.Errorf("expected a not found error, got %v", err)
+	}
+	if _, _, err = exchangerates.GetLatestExchangeRate(s, "EUR", "ERR", now, 7); err == nil || exchangerates.IsNotFound(err) {
+		t.Errorf("expected the failure of the store, got %v", err)
+	}
+}
+
+func TestGetRangeExchangeRates(t *testing.T) {
+	s := mock.New()
+	s.OnGetMonthExchangeRates = func(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+		switch {
+		case to == "ERR":
+			return nil, errors.New("connection refused")
+		case month != 3:
+			return nil, exchangerates.NotFound("No data exists")
+		}
+		return []exchangerates.DateRate{
+			{Date: time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC), Rate: 1.0514},
+			{Date: time.Date(2017, 3, 3, 0, 0, 0, 0, time.UTC), Rate: 1.0552},
+		}, nil
+	}
+
+	start, end := time.Date(2017, 2, 1, 0, 0, 0, 0, time.UTC), time.Date(2017, 4, 30, 0, 0, 0, 0, time.UTC)
+	rates, err := exchangerates.GetRangeExchangeRates(s, "EUR", "USD", start, end)
+	if err != nil || len(rates) != 2 {
+		t.Errorf("expected the rates of March, got %v %v", rates, err)
+	}
+	if _, err = exchangerates.GetRangeExchangeRates(s, "EUR", "ERR", start, end); err == nil || exchangerates.IsNotFound(err) {
+		t.Errorf("expected the failure of the store, got %v", err)
+	}
+}
@@ -4,7 +4,6 @@ import (
 	"archive/zip"
 	"bytes"
 	"encoding/csv"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -21,12 +20,14 @@ type Store struct {
 	records          [][]string
 	currencyIndexMap map[string]int //maps curreny names to indexes in records
 	dateIndexMap     map[string]int //maps dates to indexes in records
+	updated          time.Time      //when the dataset was last loaded
+	err              error          //error of the last refresh
 }
 
 // New returns a new instance of Store
 func New() *Store {
 	s := &Store{}
-	s.fetchData()
+	s.Refresh()
 	return s
 }
 
@@ -44,7 +45,7 @@ func (s *Store) GetExchangeRate(from, to string, date string) (float64, error) {
 		return 0, err
 	}
 
-	rate := calcRate(fromVal, toVal)
+	rate := exchangerates.CalcRate(fromVal, toVal)
 	return rate, nil
 }
 
@@ -63,20 +64,67 @@ func (s *Store) GetMonthExchangeRates(from, to string, year, month int) ([]excha
 			continue
 		}
 
-		rate := calcRate(fromVal, toVal)
+		rate := exchangerates.CalcRate(fromVal, toVal)
 		t, _ := time.Parse("2006-01-02", date)
 		rates = append(rates, exchangerates.DateRate{Rate: rate, Date: t})
 	}
 	if len(rates) == 0 {
-		return nil, errors.New("No data exists")
+		return nil, exchangerates.NotFound("No data exists")
 	}
 	return rates, nil
 }
 
-func (s *Store) fetchData() error {
+// GetQuotes returns the direct quotes against the euro held for the date specified
+func (s *Store) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	dateIndex, ok := s.dateIndexMap[date]
+	if !ok {
+		return nil, exchangerates.NotFound("date not found")
+	}
+
+	quotes := make([]exchangerates.Quote, 0, len(s.currencyIndexMap))
+	for curr, currIndex := range s.currencyIndexMap {
+		value, err := strconv.ParseFloat(s.records[dateIndex][currIndex], 64)
+		if err != nil {
+			continue
+		}
+		quotes = append(quotes, exchangerates.Quote{From: "EUR", To: curr, Rate: value})
+	}
+	return quotes, nil
+}
+
+// Refresh downloads the ecb dataset again, replacing the one currently held
+func (s *Store) Refresh() error {
+	err := s.fetchData()
+	s.Lock()
+	s.err = err
+	s.Unlock()
+	return err
+}
+
+// Updated returns the time the dataset was last loaded, zero if it never was
+func (s *Store) Updated() time.Time {
+	s.Lock()
+	defer s.Unlock()
+	return s.updated
+}
+
+// Status describes the dataset currently held
+func (s *Store) Status() exchangerates.Status {
 	s.Lock()
 	defer s.Unlock()
+	status := exchangerates.Status{Updated: s.updated, Rows: len(s.dateIndexMap), Err: s.err}
+	for date := range s.dateIndexMap {
+		if date > status.Latest {
+			status.Latest = date
+		}
+	}
+	return status
+}
 
+func (s *Store) fetchData() error {
 	resp, err := http.Get("https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist.zip")
 	if err != nil {
 		return err
@@ -101,23 +149,29 @@ func (s *Store) fetchData() error {
 
 	csvReader := csv.NewReader(file)
 
-	s.records, err = csvReader.ReadAll()
+	records, err := csvReader.ReadAll()
 	if err != nil {
 		return err
 	}
 
-	s.currencyIndexMap = make(map[string]int)
+	currencyIndexMap := make(map[string]int)
 
-	for i := 1; i < len(s.records[0]); i++ {
-		s.currencyIndexMap[s.records[0][i]] = i
+	for i := 1; i < len(records[0]); i++ {
+		currencyIndexMap[records[0][i]] = i
 	}
 
-	s.dateIndexMap = make(map[string]int)
+	dateIndexMap := make(map[string]int)
 
-	for i := 1; i < len(s.records); i++ {
-		s.dateIndexMap[s.records[i][0]] = i
+	for i := 1; i < len(records); i++ {
+		dateIndexMap[records[i][0]] = i
 	}
 
+	s.Lock()
+	defer s.Unlock()
+	s.records = records
+	s.currencyIndexMap = currencyIndexMap
+	s.dateIndexMap = dateIndexMap
+	s.updated = time.Now()
 	return nil
 }
 
@@ -126,27 +180,23 @@ func (s *Store) lookup(curr string, date string) (float64, error) {
 		return 1, nil
 	}
 
+	s.Lock()
+	defer s.Unlock()
+
 	dateIndex, ok := s.dateIndexMap[date]
 	if !ok {
-		return 0, errors.New("date not found")
+		return 0, exchangerates.NotFound("date not found")
 	}
 
 	currIndex, ok := s.currencyIndexMap[curr]
 	if !ok {
-		return 0, errors.New("currency " + curr + " not found")
+		return 0, exchangerates.NotFound("currency " + curr + " not found")
 	}
 
 	value, error := strconv.ParseFloat(s.records[dateIndex][currIndex], 64)
 	if error != nil {
-		return 0, errors.New(curr + " data does not exist for " + date)
+		return 0, exchangerates.NotFound(curr + " data does not exist for " + date)
 	}
 
 	return value, nil
 }
-
-func calcRate(fromVal, toVal float64) (rate float64) {
-	rate = toVal / fromVal
-	rateStr := fmt.Sprintf("%.5f", rate) //reduce precision
-	rate, _ = strconv.ParseFloat(rateStr, 5)
-	return
-}
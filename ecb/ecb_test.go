@@ -1,9 +1,10 @@
 package ecb
 
 import (
-	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
 )
 
 func TestGetExchangeRate(t *testing.T) {
@@ -39,21 +40,21 @@ func TestGetExchangeRate(t *testing.T) {
 			From:         "USD",
 			To:           "XYZ",
 			Date:         "2017-03-02",
-			ExpectedErr:  errors.New("currency XYZ not found"),
+			ExpectedErr:  exchangerates.NotFound("currency XYZ not found"),
 			ExpectedRate: 0,
 		},
 		{
 			From:         "XYZ",
 			To:           "USD",
 			Date:         "2017-03-02",
-			ExpectedErr:  errors.New("currency XYZ not found"),
+			ExpectedErr:  exchangerates.NotFound("currency XYZ not found"),
 			ExpectedRate: 0,
 		},
 		{
 			From:         "USD",
 			To:           "EUR",
 			Date:         "9999-03-02",
-			ExpectedErr:  errors.New("date not found"),
+			ExpectedErr:  exchangerates.NotFound("date not found"),
 			ExpectedRate: 0,
 		},
 	}
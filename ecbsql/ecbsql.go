@@ -1,13 +1,14 @@
 package ecbsql
 
 import (
+	"database/sql"
 	"encoding/xml"
-	"errors"
 	"fmt"
 	"io/ioutil"
 	"net/http"
 	"os"
 	"strconv"
+	"sync"
 	"time"
 
 	"github.com/farhan-shahid/exchangerates"
@@ -29,12 +30,27 @@ const passEnv = "MYSQLPASS"
 // Store fetches and stores historical currency exchange data from ecb.europa.eu into a MySQL database
 type Store struct {
 	db *sqlx.DB
+
+	mu      sync.Mutex
+	updated time.Time //when rates were last downloaded into the database
+	err     error     //error of the last load
+	latest  string    //latest date held by the database when it was last loaded
+	rows    int       //number of dates held by the database when it was last loaded
 }
 
-// New returns a new instance of Store
+// New returns a new instance of Store using the ExchangeDB database of the local MySQL server,
+// connecting as root with the password held by the MYSQLPASS environment variable
 func New() *Store {
+	return Open(fmt.Sprintf("%s:%s@/%s", dbuser, os.Getenv(passEnv), db))
+}
+
+// Open returns a new instance of Store using the MySQL database given by dsn
+func Open(dsn string) *Store {
 	s := &Store{}
-	s.fetchData()
+	s.err = s.fetchData(dsn)
+	if s.err == nil {
+		s.err = s.count()
+	}
 	return s
 }
 
@@ -52,7 +68,7 @@ func (s *Store) GetExchangeRate(from, to string, date string) (float64, error) {
 		return 0, err
 	}
 
-	rate := calcRate(fromVal, toVal)
+	rate := exchangerates.CalcRate(fromVal, toVal)
 	return rate, nil
 }
 
@@ -71,25 +87,95 @@ func (s *Store) GetMonthExchangeRates(from, to string, year, month int) ([]excha
 			continue
 		}
 
-		rate := calcRate(fromVal, toVal)
+		rate := exchangerates.CalcRate(fromVal, toVal)
 		t, _ := time.Parse("2006-01-02", date)
 		rates = append(rates, exchangerates.DateRate{Rate: rate, Date: t})
 	}
 	if len(rates) == 0 {
-		return nil, errors.New("No data exists")
+		return nil, exchangerates.NotFound("No data exists")
 	}
 	return rates, nil
 }
 
-func (s *Store) fetchData() (err error) {
-	connStr := fmt.Sprintf("%s:%s@/%s", dbuser, os.Getenv(passEnv), db)
-	s.db, err = sqlx.Connect("mysql", connStr)
+// GetQuotes returns the direct quotes stored for the date specified
+func (s *Store) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	var quotes []exchangerates.Quote
+	err := s.db.Select(&quotes, "SELECT fromCurr AS `from`, toCurr AS `to`, rate FROM ExchangeRate WHERE date=? AND fromCurr<>toCurr", date)
+	if err != nil {
+		return nil, err
+	}
+	if len(quotes) == 0 {
+		return nil, exchangerates.NotFound("date not found")
+	}
+	return quotes, nil
+}
+
+// Stats returns the statistics of the database connection pool
+func (s *Store) Stats() sql.DBStats {
+	if s.db == nil {
+		return sql.DBStats{}
+	}
+	return s.db.Stats()
+}
+
+// Refresh downloads the rates of the last 90 days into the database, replacing those already held
+func (s *Store) Refresh() error {
+	if s.db == nil {
+		return s.Status().Err
+	}
+	err := s.download()
+	if err == nil {
+		err = s.count()
+	}
+	s.mu.Lock()
+	s.err = err
+	s.mu.Unlock()
+	return err
+}
+
+// Updated returns the time rates were last downloaded into the database, zero if they were not
+// since the Store was created
+func (s *Store) Updated() time.Time {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updated
+}
+
+// Status describes the dataset held in the database when it was last loaded or refreshed, without
+// querying it, as status is reported on every health check and metrics scrape
+func (s *Store) Status() exchangerates.Status {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return exchangerates.Status{Updated: s.updated, Latest: s.latest, Rows: s.rows, Err: s.err}
+}
+
+// count records the latest date and the number of dates held by the database
+func (s *Store) count() error {
+	var latest sql.NullString
+	err := s.db.Get(&latest, `SELECT MAX(date) FROM ExchangeRate`)
+	if err != nil {
+		return err
+	}
+	var rows int
+	err = s.db.Get(&rows, `SELECT COUNT(DISTINCT date) FROM ExchangeRate`)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.latest, s.rows = latest.String, rows
+	s.mu.Unlock()
+	return nil
+}
+
+func (s *Store) fetchData(dsn string) (err error) {
+	s.db, err = sqlx.Connect("mysql", dsn)
 	if err != nil {
 		return err
 	}
 
 	var exists int
-	err = s.db.Get(&exists, `SELECT count(*) FROM information_schema.TABLES WHERE (TABLE_SCHEMA = ?) AND (TABLE_NAME = ExchangeRate)`, db)
+	err = s.db.Get(&exists, `SELECT count(*) FROM information_schema.TABLES WHERE (TABLE_SCHEMA = DATABASE()) AND (TABLE_NAME = 'ExchangeRate')`)
 	if err != nil {
 		return err
 	}
@@ -101,7 +187,11 @@ func (s *Store) fetchData() (err error) {
 	if err != nil {
 		return err
 	}
+	return s.download()
+}
 
+// download inserts the rates of the last 90 days published by the ecb
+func (s *Store) download() error {
 	resp, err := http.Get("https://www.ecb.europa.eu/stats/eurofxref/eurofxref-hist-90d.xml")
 	if err != nil {
 		return err
@@ -131,29 +221,38 @@ func (s *Store) fetchData() (err error) {
 		return err
 	}
 
-	tx := s.db.MustBegin()
+	tx, err := s.db.Beginx()
+	if err != nil {
+		return err
+	}
 	for _, i := range d.Rates {
+		if _, err = tx.Exec(`DELETE FROM ExchangeRate WHERE date=?`, i.Date); err != nil {
+			tx.Rollback()
+			return err
+		}
 		for _, j := range i.Curr {
 			tx.Exec(`INSERT INTO ExchangeRate (fromCurr, toCurr, date, rate) VALUES (?, ?, ?, ?)`, "EUR", j.Currency, i.Date, j.Rate)
 		}
 		tx.Exec(`INSERT INTO ExchangeRate (fromCurr, toCurr, date, rate) VALUES (?, ?, ?, ?)`, "EUR", "EUR", i.Date, 1)
 	}
-	err = tx.Commit()
-	return err
+	if err = tx.Commit(); err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	s.updated = time.Now()
+	s.mu.Unlock()
+	return nil
 }
 
 func (s *Store) lookup(curr string, date string) (float64, error) {
 	var value float64
 	err := s.db.Get(&value, `SELECT rate FROM ExchangeRate WHERE date=? AND fromCurr="EUR" AND toCurr=?`, date, curr)
+	if err == sql.ErrNoRows {
+		return 0, exchangerates.NotFound(curr + " data does not exist for " + date)
+	}
 	if err != nil {
 		return 0, err
 	}
 	return value, nil
 }
-
-func calcRate(fromVal, toVal float64) (rate float64) {
-	rate = toVal / fromVal
-	rateStr := fmt.Sprintf("%.5f", rate) //reduce precision
-	rate, _ = strconv.ParseFloat(rateStr, 5)
-	return
-}
@@ -0,0 +1,119 @@
+package fed
+
+import (
+	"encoding/csv"
+	"errors"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates/table"
+)
+
+const dataURL = "https://www.federalreserve.gov/datadownload/Output.aspx?rel=H10&series=60f32914ab61dfab590e0e470153e3ae&lastobs=&from=&to=&filetype=csv&label=include&layout=seriescolumn&type=package"
+
+const base = "USD"
+
+// countryCurrencies maps the country codes used in H.10 series identifiers to currency names
+var countryCurrencies = map[string]string{
+	"AL": "AUD",
+	"BZ": "BRL",
+	"CA": "CAD",
+	"CH": "CNY",
+	"DN": "DKK",
+	"EU": "EUR",
+	"HK": "HKD",
+	"IN": "INR",
+	"JA": "JPY",
+	"KO": "KRW",
+	"MA": "MYR",
+	"MX": "MXN",
+	"NO": "NOK",
+	"NZ": "NZD",
+	"SD": "SEK",
+	"SF": "ZAR",
+	"SI": "SGD",
+	"SL": "LKR",
+	"SZ": "CHF",
+	"TA": "TWD",
+	"TH": "THB",
+	"UK": "GBP",
+}
+
+// Store fetches and stores historical currency exchange data from the Federal Reserve H.10 release
+type Store struct {
+	*table.Store
+}
+
+// New returns a new instance of Store
+func New() *Store {
+	s := newStore()
+	s.Refresh()
+	return s
+}
+
+func newStore() *Store {
+	return &Store{table.New(base, dataURL, parse)}
+}
+
+// parse reads an H.10 data download in the "series in columns" CSV layout.
+// Series quoted as US dollars per unit of currency (RXI$US_*) are inverted so
+// that every value is expressed in units of currency per US dollar
+func parse(r io.Reader) (currencies map[string]bool, rates map[string]map[string]float64, err error) {
+	csvReader := csv.NewReader(r)
+	csvReader.FieldsPerRecord = -1
+
+	records, err := csvReader.ReadAll()
+	if err != nil {
+		return nil, nil, err
+	}
+
+	header := -1
+	for i, record := range records {
+		if len(record) > 0 && record[0] == "Time Period" {
+			header = i
+			break
+		}
+	}
+	if header == -1 {
+		return nil, nil, errors.New(`"Time Period" header not found`)
+	}
+
+	currencies = make(map[string]bool)
+	columns := make(map[int]string) //maps column indexes to currency names
+	inverted := make(map[int]bool)
+	for i := 1; i < len(records[header]); i++ {
+		id := records[header][i]
+		country := id[strings.LastIndex(id, ".")+1:]
+		curr, ok := countryCurrencies[country]
+		if !ok {
+			continue
+		}
+		columns[i] = curr
+		inverted[i] = strings.HasPrefix(id, "RXI$US_")
+		currencies[curr] = true
+	}
+
+	rates = make(map[string]map[string]float64)
+	for _, record := range records[header+1:] {
+		if len(record) == 0 {
+			continue
+		}
+		values := make(map[string]float64)
+		for i, curr := range columns {
+			if i >= len(record) {
+				continue
+			}
+			value, err := strconv.ParseFloat(record[i], 64)
+			if err != nil || value == 0 {
+				continue // "ND" marks days without a fixing
+			}
+			if inverted[i] {
+				value = 1 / value
+			}
+			values[curr] = value
+		}
+		rates[record[0]] = values
+	}
+	return currencies, rates, nil
+}
@@ -0,0 +1,110 @@
+package fed
+
+import (
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func TestGetExchangeRate(t *testing.T) {
+	var tests = []struct {
+		From         string
+		To           string
+		Date         string
+		ExpectedErr  error
+		ExpectedRate float64
+	}{
+		{
+			From:         "EUR",
+			To:           "USD",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 1.05,
+		},
+		{
+			From:         "USD",
+			To:           "JPY",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 114.29,
+		},
+		{
+			From:         "EUR",
+			To:           "JPY",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 120.0045,
+		},
+		{
+			From:         "GBP",
+			To:           "EUR",
+			Date:         "2017-03-02",
+			ExpectedErr:  nil,
+			ExpectedRate: 1.16667,
+		},
+		{
+			From:         "USD",
+			To:           "JPY",
+			Date:         "2017-03-03",
+			ExpectedErr:  exchangerates.NotFound("JPY data does not exist for 2017-03-03"),
+			ExpectedRate: 0,
+		},
+		{
+			From:         "USD",
+			To:           "XYZ",
+			Date:         "2017-03-02",
+			ExpectedErr:  exchangerates.NotFound("currency XYZ not found"),
+			ExpectedRate: 0,
+		},
+		{
+			From:         "USD",
+			To:           "EUR",
+			Date:         "9999-03-02",
+			ExpectedErr:  exchangerates.NotFound("date not found"),
+			ExpectedRate: 0,
+		},
+	}
+
+	f, err := os.Open("testdata/h10.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := newStore()
+	if err := s.Load(f); err != nil {
+		t.Fatal(err)
+	}
+	for i, tt := range tests {
+		got, err := s.GetExchangeRate(tt.From, tt.To, tt.Date)
+		if want, got := tt.ExpectedErr, err; !reflect.DeepEqual(want, got) {
+			t.Fatalf("#%d failed: expected error=%v, got %v", i, want, got)
+		}
+		if want, got := tt.ExpectedRate, got; want != got {
+			t.Fatalf("#%d failed: expected rate=%v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestStatus(t *testing.T) {
+	f, err := os.Open("testdata/h10.csv")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	s := newStore()
+	if status := s.Status(); status.Rows != 0 || !status.Updated.IsZero() {
+		t.Fatalf("expected an empty status before loading, got %+v", status)
+	}
+	if err := s.Load(f); err != nil {
+		t.Fatal(err)
+	}
+
+	status := s.Status()
+	if status.Rows != 3 || status.Latest != "2017-03-03" || status.Updated.IsZero() || status.Err != nil {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
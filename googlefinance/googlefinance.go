@@ -41,7 +41,7 @@ func (s *Store) GetExchangeRate(from, to string, date string) (float64, error) {
 	}
 	node, found = getNodeByAttr(node, "class", "bld")
 	if !found {
-		return 0, errors.New("currency " + from + " or " + to + " not found")
+		return 0, exchangerates.NotFound("currency " + from + " or " + to + " not found")
 	}
 
 	rate, err := strconv.ParseFloat(strings.Split(node.FirstChild.Data, " ")[0], 5)
@@ -52,6 +52,11 @@ func (s *Store) GetExchangeRate(from, to string, date string) (float64, error) {
 	return rate, nil
 }
 
+// Live reports that the rates returned are the current ones whatever the date requested
+func (s *Store) Live() bool {
+	return true
+}
+
 // GetMonthExchangeRates is not supported currently
 func (s *Store) GetMonthExchangeRates(from, to string, year, month int) ([]exchangerates.DateRate, error) {
 	return nil, errors.New("Not supported currently")
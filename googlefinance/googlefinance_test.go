@@ -1,9 +1,10 @@
 package googlefinance
 
 import (
-	"errors"
 	"reflect"
 	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
 )
 
 func TestGetExchangeRate(t *testing.T) {
@@ -39,14 +40,14 @@ func TestGetExchangeRate(t *testing.T) {
 			From:         "USD",
 			To:           "XYZ",
 			Date:         "2017-03-02",
-			ExpectedErr:  errors.New("currency USD or XYZ not found"),
+			ExpectedErr:  exchangerates.NotFound("currency USD or XYZ not found"),
 			ExpectedRate: 0,
 		},
 		{
 			From:         "XYZ",
 			To:           "USD",
 			Date:         "2017-03-02",
-			ExpectedErr:  errors.New("currency XYZ or USD not found"),
+			ExpectedErr:  exchangerates.NotFound("currency XYZ or USD not found"),
 			ExpectedRate: 0,
 		},
 	}
@@ -0,0 +1,245 @@
+// Package metrics collects metrics and exposes them in the Prometheus text format
+package metrics
+
+import (
+	"bufio"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// DefaultBuckets are the upper bounds of histogram buckets suited to latencies in seconds
+var DefaultBuckets = []float64{.001, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+// Default is the registry metrics are served from by the exchangerates server
+var Default = NewRegistry()
+
+// Sample is a value of a metric reported by a collect function, with the values of its labels
+type Sample struct {
+	Labels []string
+	Value  float64
+}
+
+// series holds the value of a metric for one combination of label values
+type series struct {
+	labels []string
+	value  float64
+	counts []uint64 // histogram observations per bucket, not cumulative
+	sum    float64
+}
+
+// family is a metric with all of its series
+type family struct {
+	name    string
+	help    string
+	typ     string
+	labels  []string
+	buckets []float64
+	collect func() []Sample
+
+	mu     sync.Mutex
+	series map[string]*series
+}
+
+func (f *family) get(values []string) *series {
+	key := strings.Join(values, "\xff")
+	s, ok := f.series[key]
+	if !ok {
+		s = &series{labels: append([]string(nil), values...)}
+		if f.buckets != nil {
+			s.counts = make([]uint64, len(f.buckets)+1)
+		}
+		f.series[key] = s
+	}
+	return s
+}
+
+// Registry holds metrics and writes them in the Prometheus text format
+type Registry struct {
+	mu       sync.Mutex
+	families map[string]*family
+}
+
+// NewRegistry returns an empty Registry
+func NewRegistry() *Registry {
+	return &Registry{families: map[string]*family{}}
+}
+
+// register adds a metric, returning the existing one if a metric with the same name was registered
+func (r *Registry) register(f *family) *family {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if existing, ok := r.families[f.name]; ok {
+		return existing
+	}
+	f.series = map[string]*series{}
+	r.families[f.name] = f
+	return f
+}
+
+// Counter is a metric that only goes up
+type Counter struct {
+	f *family
+}
+
+// Counter returns the counter with the given name and label names, registering it if needed
+func (r *Registry) Counter(name, help string, labels ...string) *Counter {
+	return &Counter{r.register(&family{name: name, help: help, typ: "counter", labels: labels})}
+}
+
+// Add adds v to the series with the given label values
+func (c *Counter) Add(v float64, values ...string) {
+	c.f.mu.Lock()
+	defer c.f.mu.Unlock()
+	c.f.get(values).value += v
+}
+
+// Inc adds one to the series with the given label values
+func (c *Counter) Inc(values ...string) {
+	c.Add(1, values...)
+}
+
+// Histogram counts observations in buckets
+type Histogram struct {
+	f *family
+}
+
+// Histogram returns the histogram with the given name, bucket upper bounds and label names,
+// registering it if needed
+func (r *Registry) Histogram(name, help string, buckets []float64, labels ...string) *Histogram {
+	return &Histogram{r.register(&family{name: name, help: help, typ: "histogram", labels: labels, buckets: buckets})}
+}
+
+// Observe records v in the series with the given label values
+func (h *Histogram) Observe(v float64, values ...string) {
+	h.f.mu.Lock()
+	defer h.f.mu.Unlock()
+	s := h.f.get(values)
+	s.counts[sort.SearchFloat64s(h.f.buckets, v)]++
+	s.sum += v
+}
+
+// GaugeFunc registers a gauge whose samples are returned by collect when the metrics are written
+func (r *Registry) GaugeFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&family{name: name, help: help, typ: "gauge", labels: labels, collect: collect})
+}
+
+// CounterFunc registers a counter whose samples are returned by collect when the metrics are written
+func (r *Registry) CounterFunc(name, help string, labels []string, collect func() []Sample) {
+	r.register(&family{name: name, help: help, typ: "counter", labels: labels, collect: collect})
+}
+
+// WriteTo writes all metrics in the Prometheus text format, sorted by name and label values
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	families := make([]*family, 0, len(r.families))
+	for _, f := range r.families {
+		families = append(families, f)
+	}
+	r.mu.Unlock()
+	sort.Slice(families, func(i, j int) bool { return families[i].name < families[j].name })
+
+	cw := &countingWriter{w: bufio.NewWriter(w)}
+	for _, f := range families {
+		f.write(cw)
+	}
+	err := cw.w.(*bufio.Writer).Flush()
+	return cw.n, err
+}
+
+func (f *family) write(w io.Writer) {
+	var samples []*series
+	if f.collect != nil {
+		for _, s := range f.collect() {
+			samples = append(samples, &series{labels: s.Labels, value: s.Value})
+		}
+	} else {
+		f.mu.Lock()
+		defer f.mu.Unlock()
+		for _, s := range f.series {
+			samples = append(samples, s)
+		}
+	}
+	sort.Slice(samples, func(i, j int) bool {
+		return strings.Join(samples[i].labels, "\xff") < strings.Join(samples[j].labels, "\xff")
+	})
+
+	io.WriteString(w, "# HELP "+f.name+" "+strings.Replace(f.help, "\n", `\n`, -1)+"\n")
+	io.WriteString(w, "# TYPE "+f.name+" "+f.typ+"\n")
+	for _, s := range samples {
+		if f.buckets == nil {
+			io.WriteString(w, f.name+labelString(f.labels, s.labels, "")+" "+formatValue(s.value)+"\n")
+			continue
+		}
+
+		var cumulative uint64
+		for i, count := range s.counts {
+			cumulative += count
+			le := math.Inf(1)
+			if i < len(f.buckets) {
+				le = f.buckets[i]
+			}
+			io.WriteString(w, f.name+"_bucket"+labelString(f.labels, s.labels, formatValue(le))+" "+strconv.FormatUint(cumulative, 10)+"\n")
+		}
+		io.WriteString(w, f.name+"_sum"+labelString(f.labels, s.labels, "")+" "+formatValue(s.sum)+"\n")
+		io.WriteString(w, f.name+"_count"+labelString(f.labels, s.labels, "")+" "+strconv.FormatUint(cumulative, 10)+"\n")
+	}
+}
+
+// labelString formats label pairs, adding an le label for histogram buckets when le is set
+func labelString(names, values []string, le string) string {
+	var pairs []string
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		pairs = append(pairs, name+`="`+escapeLabel(value)+`"`)
+	}
+	if le != "" {
+		pairs = append(pairs, `le="`+le+`"`)
+	}
+	if len(pairs) == 0 {
+		return ""
+	}
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabel(value string) string {
+	return labelEscaper.Replace(value)
+}
+
+func formatValue(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// ServeHTTP writes the metrics of the registry for Prometheus to scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	r.WriteTo(w)
+}
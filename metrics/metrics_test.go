@@ -0,0 +1,127 @@
+package metrics
+
+import (
+	"bytes"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+func TestWriteTo(t *testing.T) {
+	r := NewRegistry()
+	c := r.Counter("requests_total", "Requests served.", "route", "status")
+	c.Inc("/a", "200")
+	c.Inc("/a", "200")
+	c.Add(3, "/b\"", "404")
+
+	h := r.Histogram("latency_seconds", "Request latency.", []float64{0.1, 1}, "route")
+	h.Observe(0.05, "/a")
+	h.Observe(0.5, "/a")
+	h.Observe(5, "/a")
+
+	r.GaugeFunc("age_seconds", "Dataset age.", []string{"store"}, func() []Sample {
+		return []Sample{{Labels: []string{"fed"}, Value: 2}, {Labels: []string{"ecb"}, Value: 1.5}}
+	})
+
+	if r.Counter("requests_total", "Requests served.", "route", "status").f != c.f {
+		t.Errorf("expected registering a metric twice to return the existing one")
+	}
+
+	var buf bytes.Buffer
+	_, err := r.WriteTo(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := `# HELP age_seconds Dataset age.
+# TYPE age_seconds gauge
+age_seconds{store="ecb"} 1.5
+age_seconds{store="fed"} 2
+# HELP latency_seconds Request latency.
+# TYPE latency_seconds histogram
+latency_seconds_bucket{route="/a",le="0.1"} 1
+latency_seconds_bucket{route="/a",le="1"} 2
+latency_seconds_bucket{route="/a",le="+Inf"} 3
+latency_seconds_sum{route="/a"} 5.55
+latency_seconds_count{route="/a"} 3
+# HELP requests_total Requests served.
+# TYPE requests_total counter
+requests_total{route="/a",status="200"} 2
+requests_total{route="/b\"",status="404"} 3
+`
+	if buf.String() != expected {
+		t.Errorf("expected:\n%s\ngot:\n%s", expected, buf.String())
+	}
+}
+
+// pathMock is a mock store reporting the direct path between currencies, without quotes
+type pathMock struct {
+	m *mock.Store
+}
+
+func (s pathMock) GetExchangeRate(from, to string, date string) (float64, error) {
+	return s.m.GetExchangeRate(from, to, date)
+}
+
+func (s pathMock) GetMonthExchangeRates(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+	return s.m.GetMonthExchangeRates(from, to, year, month)
+}
+
+func (s pathMock) GetExchangeRatePath(from, to string, date string) (float64, []string, error) {
+	rate, err := s.m.GetExchangeRate(from, to, date)
+	return rate, []string{from, to}, err
+}
+
+func TestInstrument(t *testing.T) {
+	r := NewRegistry()
+	m := mock.New()
+	m.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		if to == "XYZ" {
+			return 0, errors.New("currency XYZ not found")
+		}
+		return 1.5, nil
+	}
+	s := Instrument(r, "mock", m)
+
+	if rate, err := s.GetExchangeRate("USD", "EUR", "2017-03-02"); err != nil || rate != 1.5 {
+		t.Errorf("expected rate=1.5, got %v, %v", rate, err)
+	}
+	s.GetExchangeRate("USD", "XYZ", "2017-03-02")
+
+	// the instrumented store has the capabilities of the mock store only
+	if _, ok := s.(exchangerates.Quoter); !ok {
+		t.Errorf("expected the instrumented store to provide quotes")
+	}
+	if _, ok := s.(exchangerates.PathStore); ok {
+		t.Errorf("expected the instrumented store not to report paths")
+	}
+	ps, ok := Instrument(r, "paths", pathMock{m}).(exchangerates.PathStore)
+	if !ok {
+		t.Fatal("expected an instrumented PathStore to report paths")
+	}
+	if _, ok := ps.(exchangerates.Quoter); ok {
+		t.Errorf("expected an instrumented PathStore without quotes not to provide them")
+	}
+	if rate, path, err := ps.GetExchangeRatePath("USD", "EUR", "2017-03-02"); err != nil || rate != 1.5 || len(path) != 2 {
+		t.Errorf("expected rate=1.5 with a path, got %v, %v, %v", rate, path, err)
+	}
+
+	if exchangerates.Unwrap(s) != exchangerates.Store(m) {
+		t.Errorf("expected Unwrap to return the instrumented store")
+	}
+
+	var buf bytes.Buffer
+	r.WriteTo(&buf)
+	for _, line := range []string{
+		`exchangerates_store_lookup_duration_seconds_count{store="mock",method="GetExchangeRate"} 2`,
+		`exchangerates_store_lookup_errors_total{store="mock",method="GetExchangeRate"} 1`,
+		`exchangerates_store_lookup_duration_seconds_count{store="paths",method="GetExchangeRatePath"} 1`,
+	} {
+		if !strings.Contains(buf.String(), line+"\n") {
+			t.Errorf("expected output to contain %s, got:\n%s", line, buf.String())
+		}
+	}
+}
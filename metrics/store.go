@@ -0,0 +1,101 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// store records the latency and errors of the lookups made through a Store
+type store struct {
+	name    string
+	s       exchangerates.Store
+	latency *Histogram
+	errors  *Counter
+}
+
+// quoterStore is a store instrumenting a Quoter
+type quoterStore struct {
+	*store
+}
+
+// pathStore is a store instrumenting a PathStore
+type pathStore struct {
+	*store
+}
+
+// quoterPathStore is a store instrumenting a Quoter that is also a PathStore
+type quoterPathStore struct {
+	*store
+}
+
+// Instrument returns a Store recording the latency and errors of the lookups made through s in r,
+// labelled with the store name given. The Store is a Quoter or a PathStore only when s is one, so that
+// these can be checked on it. Other optional interfaces should be checked on exchangerates.Unwrap of the Store
+func Instrument(r *Registry, name string, s exchangerates.Store) exchangerates.Store {
+	st := &store{
+		name:    name,
+		s:       s,
+		latency: r.Histogram("exchangerates_store_lookup_duration_seconds", "Latency of store lookups by store and method.", DefaultBuckets, "store", "method"),
+		errors:  r.Counter("exchangerates_store_lookup_errors_total", "Failed store lookups by store and method.", "store", "method"),
+	}
+	_, quoter := s.(exchangerates.Quoter)
+	_, paths := s.(exchangerates.PathStore)
+	switch {
+	case quoter && paths:
+		return quoterPathStore{st}
+	case quoter:
+		return quoterStore{st}
+	case paths:
+		return pathStore{st}
+	}
+	return st
+}
+
+func (s *store) observe(method string, start time.Time, err error) {
+	s.latency.Observe(time.Since(start).Seconds(), s.name, method)
+	if err != nil {
+		s.errors.Inc(s.name, method)
+	}
+}
+
+func (s *store) GetExchangeRate(from, to string, date string) (rate float64, err error) {
+	defer func(start time.Time) { s.observe("GetExchangeRate", start, err) }(time.Now())
+	return s.s.GetExchangeRate(from, to, date)
+}
+
+func (s *store) GetMonthExchangeRates(from, to string, year, month int) (rates []exchangerates.DateRate, err error) {
+	defer func(start time.Time) { s.observe("GetMonthExchangeRates", start, err) }(time.Now())
+	return s.s.GetMonthExchangeRates(from, to, year, month)
+}
+
+// Unwrap returns the instrumented store
+func (s *store) Unwrap() exchangerates.Store {
+	return s.s
+}
+
+func (s *store) getQuotes(date string) (quotes []exchangerates.Quote, err error) {
+	defer func(start time.Time) { s.observe("GetQuotes", start, err) }(time.Now())
+	return s.s.(exchangerates.Quoter).GetQuotes(date)
+}
+
+func (s *store) getExchangeRatePath(from, to string, date string) (rate float64, path []string, err error) {
+	defer func(start time.Time) { s.observe("GetExchangeRatePath", start, err) }(time.Now())
+	return s.s.(exchangerates.PathStore).GetExchangeRatePath(from, to, date)
+}
+
+func (s quoterStore) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	return s.getQuotes(date)
+}
+
+func (s pathStore) GetExchangeRatePath(from, to string, date string) (float64, []string, error) {
+	return s.getExchangeRatePath(from, to, date)
+}
+
+func (s quoterPathStore) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	return s.getQuotes(date)
+}
+
+func (s quoterPathStore) GetExchangeRatePath(from, to string, date string) (float64, []string, error) {
+	return s.getExchangeRatePath(from, to, date)
+}
@@ -10,6 +10,7 @@ import (
 type Store struct {
 	OnGetExchangeRate       func(from, to string, date string) (float64, error)
 	OnGetMonthExchangeRates func(from, to string, year, month int) ([]exchangerates.DateRate, error)
+	OnGetQuotes             func(date string) ([]exchangerates.Quote, error)
 }
 
 // New returns a new instance of Store
@@ -32,3 +33,11 @@ func (s *Store) GetMonthExchangeRates(from, to string, year, month int) ([]excha
 	}
 	return s.OnGetMonthExchangeRates(from, to, year, month)
 }
+
+// GetQuotes just calls the OnGetQuotes function that is specified by the calling context
+func (s *Store) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	if s.OnGetQuotes == nil {
+		return nil, errors.New("OnGetQuotes not set")
+	}
+	return s.OnGetQuotes(date)
+}
@@ -0,0 +1,150 @@
+// Package refresh periodically reloads the datasets of exchange rate stores
+package refresh
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Scheduler refreshes stores at a fixed interval and notifies listeners after every refresh
+type Scheduler struct {
+	Interval time.Duration
+
+	mu        sync.Mutex
+	stores    map[string]exchangerates.Refresher
+	intervals map[string]time.Duration // of the stores refreshed at their own interval
+	listeners []func(store string, err error)
+	stop      chan struct{}
+	done      chan struct{}
+}
+
+// New returns a new instance of Scheduler refreshing stores every interval
+func New(interval time.Duration) *Scheduler {
+	return &Scheduler{
+		Interval:  interval,
+		stores:    make(map[string]exchangerates.Refresher),
+		intervals: make(map[string]time.Duration),
+	}
+}
+
+// Add registers a store to be refreshed under the given name
+func (s *Scheduler) Add(name string, r exchangerates.Refresher) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stores[name] = r
+	delete(s.intervals, name)
+}
+
+// AddEvery registers a store to be refreshed under the given name every interval instead of every Interval
+func (s *Scheduler) AddEvery(name string, r exchangerates.Refresher, interval time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.stores[name] = r
+	s.intervals[name] = interval
+}
+
+// OnRefresh registers a function called with the store name and the refresh error after every refresh
+func (s *Scheduler) OnRefresh(f func(store string, err error)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.listeners = append(s.listeners, f)
+}
+
+// RefreshAll refreshes every registered store once, in order of their names
+func (s *Scheduler) RefreshAll() {
+	s.mu.Lock()
+	names := make([]string, 0, len(s.stores))
+	for name := range s.stores {
+		names = append(names, name)
+	}
+	s.mu.Unlock()
+	sort.Strings(names)
+
+	for _, name := range names {
+		s.Refresh(name)
+	}
+}
+
+// Refresh refreshes the named store and notifies the listeners
+func (s *Scheduler) Refresh(name string) error {
+	s.mu.Lock()
+	r, ok := s.stores[name]
+	listeners := s.listeners
+	s.mu.Unlock()
+	if !ok {
+		return nil
+	}
+
+	err := r.Refresh()
+	for _, f := range listeners {
+		f(name, err)
+	}
+	return err
+}
+
+// Start begins refreshing the stores in the background, every Interval unless they were added with
+// their own interval. Stores added afterwards are refreshed in the background once restarted
+func (s *Scheduler) Start() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.stop != nil {
+		return
+	}
+
+	groups := make(map[time.Duration][]string)
+	for name := range s.stores {
+		interval, ok := s.intervals[name]
+		if !ok {
+			interval = s.Interval
+		}
+		if interval > 0 {
+			groups[interval] = append(groups[interval], name)
+		}
+	}
+	if len(groups) == 0 {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+
+	var wg sync.WaitGroup
+	for interval, names := range groups {
+		sort.Strings(names)
+		wg.Add(1)
+		go func(interval time.Duration, names []string, stop chan struct{}) {
+			defer wg.Done()
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ticker.C:
+					for _, name := range names {
+						s.Refresh(name)
+					}
+				case <-stop:
+					return
+				}
+			}
+		}(interval, names, s.stop)
+	}
+	go func(done chan struct{}) {
+		wg.Wait()
+		close(done)
+	}(s.done)
+}
+
+// Stop stops the background refreshes started by Start and waits for a running refresh to finish
+func (s *Scheduler) Stop() {
+	s.mu.Lock()
+	stop, done := s.stop, s.done
+	s.stop, s.done = nil, nil
+	s.mu.Unlock()
+	if stop == nil {
+		return
+	}
+	close(stop)
+	<-done
+}
@@ -0,0 +1,96 @@
+package refresh
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"time"
+)
+
+type testStore struct {
+	refreshes int
+	err       error
+}
+
+func (s *testStore) Refresh() error {
+	s.refreshes++
+	return s.err
+}
+
+func TestRefreshAll(t *testing.T) {
+	a, b := &testStore{}, &testStore{err: errors.New("download failed")}
+
+	s := New(time.Hour)
+	s.Add("a", a)
+	s.Add("b", b)
+
+	var got []string
+	s.OnRefresh(func(store string, err error) {
+		if err != nil {
+			store += ": " + err.Error()
+		}
+		got = append(got, store)
+	})
+	s.RefreshAll()
+
+	if want := []string{"a", "b: download failed"}; !reflect.DeepEqual(want, got) {
+		t.Fatalf("expected notifications=%v, got %v", want, got)
+	}
+	if a.refreshes != 1 || b.refreshes != 1 {
+		t.Fatalf("expected one refresh per store, got %d and %d", a.refreshes, b.refreshes)
+	}
+}
+
+func TestStartStop(t *testing.T) {
+	a := &testStore{}
+	s := New(time.Millisecond)
+	s.Add("a", a)
+
+	refreshed := make(chan struct{}, 1)
+	s.OnRefresh(func(string, error) {
+		select {
+		case refreshed <- struct{}{}:
+		default:
+		}
+	})
+
+	s.Start()
+	select {
+	case <-refreshed:
+	case <-time.After(time.Second):
+		t.Fatal("store was not refreshed")
+	}
+	s.Stop()
+
+	n := a.refreshes
+	time.Sleep(10 * time.Millisecond)
+	if a.refreshes != n {
+		t.Fatal("store refreshed after Stop")
+	}
+}
+
+func TestAddEvery(t *testing.T) {
+	a, b := &testStore{}, &testStore{}
+	s := New(0)
+	s.AddEvery("a", a, time.Millisecond)
+	s.Add("b", b)
+
+	refreshed := make(chan string, 1)
+	s.OnRefresh(func(store string, err error) {
+		select {
+		case refreshed <- store:
+		default:
+		}
+	})
+
+	s.Start()
+	defer s.Stop()
+	select {
+	case store := <-refreshed:
+		if store != "a" {
+			t.Fatalf("expected a to be refreshed, got %s", store)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("store added with its own interval was not refreshed")
+	}
+}
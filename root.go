@@ -1,6 +1,8 @@
 package exchangerates
 
 import (
+	"fmt"
+	"strconv"
 	"time"
 )
 
@@ -10,8 +12,102 @@ type Store interface {
 	GetMonthExchangeRates(from, to string, year, month int) ([]DateRate, error)
 }
 
+// Quoter is implemented by stores that can list the direct quotes they hold for a date
+type Quoter interface {
+	GetQuotes(date string) ([]Quote, error)
+}
+
+// PathStore is implemented by stores that triangulate exchange rates and can
+// report the currencies the rate was computed through, starting with from and ending with to
+type PathStore interface {
+	GetExchangeRatePath(from, to string, date string) (float64, []string, error)
+}
+
+// Refresher is implemented by stores whose dataset can be reloaded from its source
+type Refresher interface {
+	Refresh() error
+}
+
+// Updater is implemented by stores that can report when their dataset was last loaded
+type Updater interface {
+	Updated() time.Time
+}
+
+// Live is implemented by stores that may answer with the current exchange rate whatever the date
+// requested, so that their answers change at any time
+type Live interface {
+	Live() bool
+}
+
+// StatusReporter is implemented by stores that can describe the dataset they hold
+type StatusReporter interface {
+	Status() Status
+}
+
+// Status describes the dataset held by a store
+type Status struct {
+	Updated time.Time // when the dataset was last loaded, zero if it is not known
+	Latest  string    // latest date with exchange rates
+	Rows    int       // number of dates with exchange rates
+	Err     error     // error of the last load, nil if it succeeded
+}
+
+// Wrapper is implemented by stores that decorate another store
+type Wrapper interface {
+	Unwrap() Store
+}
+
+// Unwrap returns the store under the decorators wrapping s. Optional interfaces
+// other than Quoter and PathStore should be checked on it
+func Unwrap(s Store) Store {
+	for {
+		w, ok := s.(Wrapper)
+		if !ok {
+			return s
+		}
+		s = w.Unwrap()
+	}
+}
+
+// NotFoundError is returned by stores holding no exchange rate for the currencies or date requested,
+// as opposed to failing to reach their data
+type NotFoundError struct {
+	Msg string
+}
+
+func (e *NotFoundError) Error() string {
+	return e.Msg
+}
+
+// NotFound returns a *NotFoundError with the given message
+func NotFound(msg string) error {
+	return &NotFoundError{Msg: msg}
+}
+
+// IsNotFound reports whether err is a *NotFoundError
+func IsNotFound(err error) bool {
+	_, ok := err.(*NotFoundError)
+	return ok
+}
+
+// Quote represents a direct exchange rate: one unit of From is worth Rate units of To
+type Quote struct {
+	From string
+	To   string
+	Rate float64
+}
+
 // DateRate represents a single exchange rate with its date
 type DateRate struct {
 	Date time.Time
 	Rate float64
 }
+
+// CalcRate returns the exchange rate between two currencies given their values
+// against a common base currency, rounded to 5 decimal places
+func CalcRate(fromVal, toVal float64) (rate float64) {
+	rate = toVal / fromVal
+	rateStr := fmt.Sprintf("%.5f", rate) //reduce precision
+	rate, _ = strconv.ParseFloat(rateStr, 5)
+	return
+}
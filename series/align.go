@@ -0,0 +1,61 @@
+package series
+
+import (
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Days returns every day between start and end inclusive, leaving out
+// Saturdays and Sundays when weekdaysOnly is set
+func Days(start, end time.Time, weekdaysOnly bool) []time.Time {
+	var days []time.Time
+	for day := Daily.Start(start); !day.After(end); day = day.AddDate(0, 0, 1) {
+		if weekdaysOnly && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+			continue
+		}
+		days = append(days, day)
+	}
+	return days
+}
+
+// FillForward aligns a series sorted by date to the given calendar, carrying the last known
+// rate forward over days without one. Days before the first rate of the series are left out
+func FillForward(rates []exchangerates.DateRate, calendar []time.Time) []exchangerates.DateRate {
+	var (
+		result []exchangerates.DateRate
+		i      int
+		last   *exchangerates.DateRate
+	)
+	for _, day := range calendar {
+		for i < len(rates) && !Daily.Start(rates[i].Date).After(day) {
+			last = &rates[i]
+			i++
+		}
+		if last == nil {
+			continue
+		}
+		result = append(result, exchangerates.DateRate{Date: day, Rate: last.Rate})
+	}
+	return result
+}
+
+// Align returns the rates of two series sorted by date restricted to the days present in both
+func Align(a, b []exchangerates.DateRate) ([]exchangerates.DateRate, []exchangerates.DateRate) {
+	var alignedA, alignedB []exchangerates.DateRate
+	for i, j := 0, 0; i < len(a) && j < len(b); {
+		dayA, dayB := Daily.Start(a[i].Date), Daily.Start(b[j].Date)
+		switch {
+		case dayA.Before(dayB):
+			i++
+		case dayB.Before(dayA):
+			j++
+		default:
+			alignedA = append(alignedA, a[i])
+			alignedB = append(alignedB, b[j])
+			i++
+			j++
+		}
+	}
+	return alignedA, alignedB
+}
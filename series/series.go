@@ -0,0 +1,153 @@
+// Package series provides aggregation of exchange rate series into calendar periods
+package series
+
+import (
+	"errors"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// Period is the length of the calendar periods a series is resampled into
+type Period int
+
+// Periods supported for resampling
+const (
+	Daily Period = iota
+	Weekly
+	Monthly
+	Quarterly
+	Yearly
+)
+
+// ParsePeriod returns the Period with the given name
+func ParsePeriod(name string) (Period, error) {
+	switch name {
+	case "daily", "":
+		return Daily, nil
+	case "weekly":
+		return Weekly, nil
+	case "monthly":
+		return Monthly, nil
+	case "quarterly":
+		return Quarterly, nil
+	case "yearly":
+		return Yearly, nil
+	}
+	return 0, errors.New("unknown period " + name)
+}
+
+// Start returns the first day of the period containing t. Weeks start on Monday
+func (p Period) Start(t time.Time) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+	switch p {
+	case Weekly:
+		return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+	case Monthly:
+		return day.AddDate(0, 0, 1-day.Day())
+	case Quarterly:
+		return time.Date(day.Year(), day.Month()-(day.Month()-1)%3, 1, 0, 0, 0, 0, time.UTC)
+	case Yearly:
+		return time.Date(day.Year(), time.January, 1, 0, 0, 0, 0, time.UTC)
+	}
+	return day
+}
+
+// End returns the last day of the period containing t
+func (p Period) End(t time.Time) time.Time {
+	start := p.Start(t)
+	switch p {
+	case Weekly:
+		return start.AddDate(0, 0, 6)
+	case Monthly:
+		return start.AddDate(0, 1, -1)
+	case Quarterly:
+		return start.AddDate(0, 3, -1)
+	case Yearly:
+		return start.AddDate(1, 0, -1)
+	}
+	return start
+}
+
+// OHLC holds the opening, highest, lowest and closing rates of a period
+type OHLC struct {
+	Date  time.Time
+	Open  float64
+	High  float64
+	Low   float64
+	Close float64
+}
+
+// group splits a series sorted by date into the periods its rates fall in
+func group(rates []exchangerates.DateRate, p Period) [][]exchangerates.DateRate {
+	var groups [][]exchangerates.DateRate
+	for i, r := range rates {
+		if i == 0 || !p.Start(r.Date).Equal(p.Start(rates[i-1].Date)) {
+			groups = append(groups, nil)
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], r)
+	}
+	return groups
+}
+
+// Last returns the last rate of every period, dated at the start of the period
+func Last(rates []exchangerates.DateRate, p Period) []exchangerates.DateRate {
+	var result []exchangerates.DateRate
+	for _, g := range group(rates, p) {
+		result = append(result, exchangerates.DateRate{Date: p.Start(g[0].Date), Rate: g[len(g)-1].Rate})
+	}
+	return result
+}
+
+// PeriodEnd returns the last rate of every period, dated at the end of the period
+func PeriodEnd(rates []exchangerates.DateRate, p Period) []exchangerates.DateRate {
+	var result []exchangerates.DateRate
+	for _, g := range group(rates, p) {
+		result = append(result, exchangerates.DateRate{Date: p.End(g[0].Date), Rate: g[len(g)-1].Rate})
+	}
+	return result
+}
+
+// Average returns the mean rate of every period, dated at the start of the period
+func Average(rates []exchangerates.DateRate, p Period) []exchangerates.DateRate {
+	var result []exchangerates.DateRate
+	for _, g := range group(rates, p) {
+		sum := 0.0
+		for _, r := range g {
+			sum += r.Rate
+		}
+		result = append(result, exchangerates.DateRate{Date: p.Start(g[0].Date), Rate: exchangerates.CalcRate(1, sum/float64(len(g)))})
+	}
+	return result
+}
+
+// Bars returns the opening, highest, lowest and closing rates of every period, dated at the start of the period
+func Bars(rates []exchangerates.DateRate, p Period) []OHLC {
+	var result []OHLC
+	for _, g := range group(rates, p) {
+		bar := OHLC{Date: p.Start(g[0].Date), Open: g[0].Rate, High: g[0].Rate, Low: g[0].Rate, Close: g[len(g)-1].Rate}
+		for _, r := range g {
+			if r.Rate > bar.High {
+				bar.High = r.Rate
+			}
+			if r.Rate < bar.Low {
+				bar.Low = r.Rate
+			}
+		}
+		result = append(result, bar)
+	}
+	return result
+}
+
+// Resample aggregates a series into periods using the named method: last, end or avg
+func Resample(rates []exchangerates.DateRate, p Period, method string) ([]exchangerates.DateRate, error) {
+	switch method {
+	case "last", "":
+		return Last(rates, p), nil
+	case "end":
+		return PeriodEnd(rates, p), nil
+	case "avg":
+		return Average(rates, p), nil
+	}
+	return nil, errors.New("unknown method " + method)
+}
@@ -0,0 +1,111 @@
+package series
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func date(s string) time.Time {
+	t, _ := time.Parse("2006-01-02", s)
+	return t
+}
+
+var testRates = []exchangerates.DateRate{
+	{Date: date("2017-02-27"), Rate: 1.06},
+	{Date: date("2017-02-28"), Rate: 1.07},
+	{Date: date("2017-03-01"), Rate: 1.05},
+	{Date: date("2017-03-02"), Rate: 1.04},
+	{Date: date("2017-03-06"), Rate: 1.08},
+}
+
+func TestLast(t *testing.T) {
+	var tests = []struct {
+		Period   Period
+		Expected []exchangerates.DateRate
+	}{
+		{
+			Period:   Daily,
+			Expected: testRates,
+		},
+		{
+			Period: Weekly,
+			Expected: []exchangerates.DateRate{
+				{Date: date("2017-02-27"), Rate: 1.04},
+				{Date: date("2017-03-06"), Rate: 1.08},
+			},
+		},
+		{
+			Period: Monthly,
+			Expected: []exchangerates.DateRate{
+				{Date: date("2017-02-01"), Rate: 1.07},
+				{Date: date("2017-03-01"), Rate: 1.08},
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		if want, got := tt.Expected, Last(testRates, tt.Period); !reflect.DeepEqual(want, got) {
+			t.Fatalf("#%d failed: expected %v, got %v", i, want, got)
+		}
+	}
+}
+
+func TestAverage(t *testing.T) {
+	expected := []exchangerates.DateRate{
+		{Date: date("2017-02-01"), Rate: 1.065},
+		{Date: date("2017-03-01"), Rate: 1.05667},
+	}
+	if got := Average(testRates, Monthly); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestBars(t *testing.T) {
+	expected := []OHLC{
+		{Date: date("2017-02-27"), Open: 1.06, High: 1.07, Low: 1.04, Close: 1.04},
+		{Date: date("2017-03-06"), Open: 1.08, High: 1.08, Low: 1.08, Close: 1.08},
+	}
+	if got := Bars(testRates, Weekly); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestPeriodEnd(t *testing.T) {
+	expected := []exchangerates.DateRate{
+		{Date: date("2017-03-31"), Rate: 1.08},
+	}
+	if got := PeriodEnd(testRates[2:], Quarterly); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestFillForward(t *testing.T) {
+	expected := []exchangerates.DateRate{
+		{Date: date("2017-03-01"), Rate: 1.05},
+		{Date: date("2017-03-02"), Rate: 1.04},
+		{Date: date("2017-03-03"), Rate: 1.04},
+		{Date: date("2017-03-06"), Rate: 1.08},
+	}
+	calendar := Days(date("2017-02-28"), date("2017-03-06"), true)
+	if got := FillForward(testRates[2:], calendar); !reflect.DeepEqual(expected, got) {
+		t.Fatalf("expected %v, got %v", expected, got)
+	}
+}
+
+func TestAlign(t *testing.T) {
+	other := []exchangerates.DateRate{
+		{Date: date("2017-02-28"), Rate: 0.85},
+		{Date: date("2017-03-02"), Rate: 0.86},
+		{Date: date("2017-03-03"), Rate: 0.87},
+	}
+	gotA, gotB := Align(testRates, other)
+	if want := []exchangerates.DateRate{testRates[1], testRates[3]}; !reflect.DeepEqual(want, gotA) {
+		t.Fatalf("expected %v, got %v", want, gotA)
+	}
+	if want := other[:2]; !reflect.DeepEqual(want, gotB) {
+		t.Fatalf("expected %v, got %v", want, gotB)
+	}
+}
@@ -0,0 +1,204 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// LogFormat is the format access log entries are written in
+type LogFormat int
+
+// Formats of the access log
+const (
+	JSONLog     LogFormat = iota // one JSON object per line
+	CombinedLog                  // Apache combined format followed by the request ID, store and duration
+)
+
+// ParseLogFormat returns the LogFormat with the given name, json or combined
+func ParseLogFormat(name string) (LogFormat, error) {
+	switch name {
+	case "json":
+		return JSONLog, nil
+	case "combined":
+		return CombinedLog, nil
+	}
+	return 0, errors.New("unknown log format " + name + ", should be json or combined")
+}
+
+// RequestIDHeader is the header carrying the ID of a request, propagated from the client when
+// it sends one and generated otherwise
+const RequestIDHeader = "X-Request-ID"
+
+const requestIDContext contextKey = iota + 1
+
+// RequestID returns the ID given to the request by the access log, if any
+func RequestID(req *http.Request) string {
+	id, _ := req.Context().Value(requestIDContext).(string)
+	return id
+}
+
+// validRequestID reports whether an ID sent by a client can be used as is
+func validRequestID(id string) bool {
+	if id == "" || len(id) > 128 {
+		return false
+	}
+	for _, c := range id {
+		if c <= ' ' || c > '~' || c == '"' {
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// accessEntry is an entry of the access log
+type accessEntry struct {
+	Time      time.Time `json:"time"`
+	RequestID string    `json:"requestId"`
+	RemoteIP  string    `json:"remoteIp"`
+	Method    string    `json:"method"`
+	Path      string    `json:"path"`
+	Query     string    `json:"query,omitempty"`
+	Proto     string    `json:"proto"`
+	Status    int       `json:"status"`
+	Bytes     int       `json:"bytes"`
+	Duration  float64   `json:"durationMs"`
+	Store     string    `json:"store,omitempty"`
+	Referer   string    `json:"referer,omitempty"`
+	UserAgent string    `json:"userAgent,omitempty"`
+}
+
+// accessLogger writes an entry for every request handled by h
+type accessLogger struct {
+	mu         sync.Mutex
+	w          io.Writer
+	format     LogFormat
+	trustProxy bool
+	router     *mux.Router
+	h          http.Handler
+}
+
+// SetAccessLog logs every request to w in the given format. When trustProxy is set the client
+// address is taken from the X-Forwarded-For header set by a proxy in front of the server
+func (s *Server) SetAccessLog(w io.Writer, format LogFormat, trustProxy bool) {
+	s.accessLog = &accessLogger{w: w, format: format, trustProxy: trustProxy, router: s.router, h: s.h}
+	s.h = s.accessLog
+}
+
+// SetLogFormat changes the format of the access log set by SetAccessLog, while the server handles requests
+func (s *Server) SetLogFormat(format LogFormat) {
+	if s.accessLog == nil {
+		return
+	}
+	s.accessLog.mu.Lock()
+	defer s.accessLog.mu.Unlock()
+	s.accessLog.format = format
+}
+
+func (l *accessLogger) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	start := time.Now()
+
+	id := req.Header.Get(RequestIDHeader)
+	if !validRequestID(id) {
+		id = newRequestID()
+	}
+	w.Header().Set(RequestIDHeader, id)
+	req = req.WithContext(context.WithValue(req.Context(), requestIDContext, id))
+
+	sw := &statusWriter{ResponseWriter: w}
+	l.h.ServeHTTP(sw, req)
+
+	e := &accessEntry{
+		Time:      start,
+		RequestID: id,
+		RemoteIP:  l.remoteIP(req),
+		Method:    req.Method,
+		Path:      req.URL.Path,
+		Query:     redactQuery(req.URL.Query()),
+		Proto:     req.Proto,
+		Status:    sw.code(),
+		Bytes:     sw.size,
+		Duration:  float64(time.Since(start)) / float64(time.Millisecond),
+		Store:     l.store(req),
+		Referer:   req.Referer(),
+		UserAgent: req.UserAgent(),
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	var line []byte
+	if l.format == CombinedLog {
+		line = []byte(e.combined())
+	} else {
+		line, _ = json.Marshal(e)
+		line = append(line, '\n')
+	}
+	l.w.Write(line)
+}
+
+// remoteIP returns the address of the client
+func (l *accessLogger) remoteIP(req *http.Request) string {
+	if forwarded := req.Header.Get("X-Forwarded-For"); l.trustProxy && forwarded != "" {
+		return strings.TrimSpace(strings.Split(forwarded, ",")[0])
+	}
+	host, _, err := net.SplitHostPort(req.RemoteAddr)
+	if err != nil {
+		return req.RemoteAddr
+	}
+	return host
+}
+
+// store returns the name of the store the request is for, given in the path or the store parameter
+func (l *accessLogger) store(req *http.Request) string {
+	var match mux.RouteMatch
+	if l.router != nil && l.router.Match(req, &match) && match.Vars["store"] != "" {
+		return match.Vars["store"]
+	}
+	return req.URL.Query().Get("store")
+}
+
+// redactQuery encodes the query of a request without the value of the API key it may hold
+func redactQuery(query url.Values) string {
+	if _, ok := query["api_key"]; ok {
+		query.Set("api_key", "REDACTED")
+	}
+	return query.Encode()
+}
+
+// combined formats the entry in the Apache combined format, followed by the request ID,
+// the store and the duration in milliseconds
+func (e *accessEntry) combined() string {
+	uri := e.Path
+	if e.Query != "" {
+		uri += "?" + e.Query
+	}
+	bytes := "-"
+	if e.Bytes > 0 {
+		bytes = fmt.Sprint(e.Bytes)
+	}
+	store := e.Store
+	if store == "" {
+		store = "-"
+	}
+	return fmt.Sprintf("%s - - [%s] %q %d %s %q %q %q %q %.3f\n",
+		e.RemoteIP, e.Time.Format("02/Jan/2006:15:04:05 -0700"), e.Method+" "+uri+" "+e.Proto,
+		e.Status, bytes, e.Referer, e.UserAgent, e.RequestID, store, e.Duration)
+}
@@ -0,0 +1,90 @@
+package server
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strings"
+	"testing"
+)
+
+func TestAccessLogJSON(t *testing.T) {
+	s := New()
+	var buf bytes.Buffer
+	s.SetAccessLog(&buf, JSONLog, true)
+
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.0, nil
+	}
+
+	req, err := http.NewRequest("GET", "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02&api_key=secret", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7, 10.0.0.1")
+	req.Header.Set(RequestIDHeader, "abc-123")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	if id := rr.Header().Get(RequestIDHeader); id != "abc-123" {
+		t.Errorf("expected the request ID to be propagated, got %q", id)
+	}
+
+	var e accessEntry
+	err = json.Unmarshal(buf.Bytes(), &e)
+	if err != nil {
+		t.Fatalf("%v: %s", err, buf.String())
+	}
+	if e.RequestID != "abc-123" || e.RemoteIP != "203.0.113.7" || e.Method != "GET" ||
+		e.Path != "/v1/stores/mock/rate" || e.Status != http.StatusOK || e.Bytes != rr.Body.Len() ||
+		e.Store != "mock" || e.Query != "api_key=REDACTED&date=2017-03-02&from=USD&to=EUR" {
+		t.Errorf("unexpected entry %s", buf.String())
+	}
+}
+
+func TestAccessLogCombined(t *testing.T) {
+	s := New()
+	var buf bytes.Buffer
+	s.SetAccessLog(&buf, CombinedLog, false)
+
+	req, err := http.NewRequest("GET", "/matrix?store=mock&date=20-03-02&currencies=USD", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.RemoteAddr = "10.0.0.1:5555"
+	req.Header.Set("X-Forwarded-For", "203.0.113.7")
+	req.Header.Set(RequestIDHeader, "bad id")
+	req.Header.Set("User-Agent", "test")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	id := rr.Header().Get(RequestIDHeader)
+	if !regexp.MustCompile(`^[0-9a-f]{32}$`).MatchString(id) {
+		t.Errorf("expected an invalid request ID to be replaced, got %q", id)
+	}
+
+	expected := regexp.MustCompile(`^10\.0\.0\.1 - - \[[^\]]+\] "GET /matrix\?currencies=USD&date=20-03-02&store=mock HTTP/1.1" 400 \d+ "" "test" "` + id + `" "mock" \d+\.\d{3}\n$`)
+	if !expected.MatchString(buf.String()) {
+		t.Errorf("unexpected entry %q", buf.String())
+	}
+}
+
+func TestSetLogFormat(t *testing.T) {
+	s := New()
+	var buf bytes.Buffer
+	s.SetAccessLog(&buf, JSONLog, false)
+	s.SetLogFormat(CombinedLog)
+
+	req, err := http.NewRequest("GET", "/healthz", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.ServeHTTP(httptest.NewRecorder(), req)
+
+	if !strings.Contains(buf.String(), `"GET /healthz HTTP/1.1" 200`) {
+		t.Errorf("expected a combined log entry, got %q", buf.String())
+	}
+}
@@ -0,0 +1,130 @@
+package server
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+
+	"github.com/farhan-shahid/exchangerates/alert"
+	"github.com/gorilla/mux"
+)
+
+// errAlertsAuth is returned by the alert rule endpoints when API keys are not required, as the rules
+// make the server send requests to the webhooks of whoever creates them
+var errAlertsAuth = &apiError{Status: http.StatusNotFound, Code: "auth_disabled", Message: "alerts require API keys, which are not enabled"}
+
+// SetAlerts enables the alert rule endpoints backed by the given manager. They are only served when
+// API keys are required, each key managing its own rules
+func (s *Server) SetAlerts(m *alert.Manager) {
+	s.alerts = m
+}
+
+// alertOwner returns the owner of the rules managed by req, identifying its API key without storing it,
+// and false when keys are not required
+func (s *Server) alertOwner(req *http.Request) (string, bool) {
+	key, _ := req.Context().Value(apiKeyContext).(string)
+	if s.keys == nil || key == "" {
+		return "", false
+	}
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:]), true
+}
+
+func (s *Server) alertsHandler(w http.ResponseWriter, req *http.Request) {
+	if s.alerts == nil {
+		http.Error(w, "alerts are not enabled", http.StatusNotFound)
+		return
+	}
+	owner, ok := s.alertOwner(req)
+	if !ok {
+		http.Error(w, errAlertsAuth.Message, errAlertsAuth.Status)
+		return
+	}
+
+	id := mux.Vars(req)["id"]
+	switch {
+	case req.Method == "GET" && id == "":
+		writeAlertJSON(w, http.StatusOK, maskSecrets(s.alerts.List(owner)))
+	case req.Method == "POST" && id == "":
+		var r alert.Rule
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r.Owner = owner
+		r, err = s.alerts.Create(r)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeAlertJSON(w, http.StatusCreated, maskSecrets([]alert.Rule{r})[0])
+	case req.Method == "GET":
+		r, err := s.alerts.Get(owner, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		writeAlertJSON(w, http.StatusOK, maskSecrets([]alert.Rule{r})[0])
+	case req.Method == "PUT":
+		var r alert.Rule
+		err := json.NewDecoder(req.Body).Decode(&r)
+		if err != nil {
+			http.Error(w, "invalid request body: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		r, err = s.alerts.Update(owner, id, r)
+		if err == alert.ErrNotFound {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeAlertJSON(w, http.StatusOK, maskSecrets([]alert.Rule{r})[0])
+	case req.Method == "DELETE":
+		err := s.alerts.Delete(owner, id)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusNotFound)
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (s *Server) alertHistoryHandler(w http.ResponseWriter, req *http.Request) {
+	if s.alerts == nil {
+		http.Error(w, "alerts are not enabled", http.StatusNotFound)
+		return
+	}
+	owner, ok := s.alertOwner(req)
+	if !ok {
+		http.Error(w, errAlertsAuth.Message, errAlertsAuth.Status)
+		return
+	}
+
+	history, err := s.alerts.History(owner, mux.Vars(req)["id"])
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusNotFound)
+		return
+	}
+	writeAlertJSON(w, http.StatusOK, history)
+}
+
+// maskSecrets removes the webhook signing secrets and the owners from rules sent to clients
+func maskSecrets(rules []alert.Rule) []alert.Rule {
+	for i := range rules {
+		rules[i].Secret, rules[i].Owner = "", ""
+	}
+	return rules
+}
+
+func writeAlertJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
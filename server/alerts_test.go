@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates/alert"
+	"github.com/farhan-shahid/exchangerates/auth"
+)
+
+func TestAlertsHandler(t *testing.T) {
+	s := New()
+	req, _ := http.NewRequest("GET", "/alerts", nil)
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || strings.TrimSpace(rr.Body.String()) != "alerts are not enabled" {
+		t.Fatalf("expected alerts to be disabled, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	m, err := alert.New("", LookupStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetAlerts(m)
+
+	// rules make the server send requests, so that only the clients with an API key manage them
+	req, _ = http.NewRequest("GET", "/alerts", nil)
+	rr = httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusNotFound || strings.TrimSpace(rr.Body.String()) != "alerts require API keys, which are not enabled" {
+		t.Fatalf("expected alerts to require API keys, got %d %q", rr.Code, rr.Body.String())
+	}
+
+	keys, err := auth.New(keySource{{Key: "k1", Name: "alice"}, {Key: "k2", Name: "bob"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetAuth(keys)
+
+	var created alert.Rule
+	var tests = []struct {
+		key, method, url, body string
+		ExpectedCode           int
+		ExpectedBody           string // contained in the response
+	}{
+		{"k1", "POST", "/alerts", `{"Store":"mock","From":"EUR","To":"USD","Condition":"above","Threshold":1.1,"Webhook":"http://example.com/hook","Secret":"s3cret","Owner":"k2"}`, http.StatusCreated, `"Condition":"above"`},
+		{"k1", "POST", "/alerts", `{"Store":"mock","From":"EUR","To":"USD","Condition":"sideways","Webhook":"http://example.com/hook"}`, http.StatusBadRequest, "condition should be one of above, below or change"},
+		{"k1", "POST", "/alerts", `{"Store":"xyz","From":"EUR","To":"USD","Condition":"above","Webhook":"http://example.com/hook"}`, http.StatusBadRequest, "xyz is not a valid store"},
+		{"k1", "POST", "/alerts", `{`, http.StatusBadRequest, "invalid request body"},
+		{"k1", "GET", "/alerts", "", http.StatusOK, `"Threshold":1.1`},
+		{"k1", "GET", "/alerts/{id}", "", http.StatusOK, `"Webhook":"http://example.com/hook"`},
+		{"k1", "GET", "/alerts/missing", "", http.StatusNotFound, "alert not found"},
+		{"k2", "GET", "/alerts", "", http.StatusOK, "[]"},
+		{"k2", "GET", "/alerts/{id}", "", http.StatusNotFound, "alert not found"},
+		{"k2", "PUT", "/alerts/{id}", `{"Store":"mock","From":"EUR","To":"USD","Condition":"below","Threshold":1,"Webhook":"http://example.com/hook"}`, http.StatusNotFound, "alert not found"},
+		{"k2", "DELETE", "/alerts/{id}", "", http.StatusNotFound, "alert not found"},
+		{"k2", "GET", "/alerts/{id}/history", "", http.StatusNotFound, "alert not found"},
+		{"", "GET", "/alerts", "", http.StatusUnauthorized, "missing API key"},
+		{"k1", "PUT", "/alerts/{id}", `{"Store":"mock","From":"EUR","To":"USD","Condition":"below","Threshold":1,"Webhook":"http://example.com/hook"}`, http.StatusOK, `"Condition":"below"`},
+		{"k1", "PUT", "/alerts/missing", `{"Store":"mock","From":"EUR","To":"USD","Condition":"below","Threshold":1,"Webhook":"http://example.com/hook"}`, http.StatusNotFound, "alert not found"},
+		{"k1", "PUT", "/alerts/{id}", `{"Store":"mock","From":"EUR","To":"USD","Condition":"change","Threshold":-1,"Webhook":"http://example.com/hook"}`, http.StatusBadRequest, "threshold of a change condition should be a positive percentage"},
+		{"k1", "GET", "/alerts/{id}/history", "", http.StatusOK, "[]"},
+		{"k1", "GET", "/alerts/missing/history", "", http.StatusNotFound, "alert not found"},
+		{"k1", "DELETE", "/alerts/{id}", "", http.StatusNoContent, ""},
+		{"k1", "DELETE", "/alerts/{id}", "", http.StatusNotFound, "alert not found"},
+		{"k1", "GET", "/alerts/{id}", "", http.StatusNotFound, "alert not found"},
+	}
+
+	for i, tt := range tests {
+		req, err := http.NewRequest(tt.method, strings.Replace(tt.url, "{id}", created.ID, 1), strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if tt.key != "" {
+			req.Header.Set("X-API-Key", tt.key)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v: %s", i, tt.ExpectedCode, rr.Code, rr.Body.String())
+			continue
+		}
+		if !strings.Contains(rr.Body.String(), tt.ExpectedBody) {
+			t.Errorf("#%d failed: expected body containing %q, got %q", i, tt.ExpectedBody, rr.Body.String())
+		}
+		if strings.Contains(rr.Body.String(), "s3cret") {
+			t.Errorf("#%d failed: the webhook secret was sent to the client: %s", i, rr.Body.String())
+		}
+		if i == 0 {
+			if err := json.NewDecoder(rr.Body).Decode(&created); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if tt.method == "PUT" && rr.Code == http.StatusOK {
+			owner, _ := s.alertOwner(req.WithContext(context.WithValue(req.Context(), apiKeyContext, tt.key)))
+			if r, err := m.Get(owner, created.ID); err != nil || r.Secret != "s3cret" {
+				t.Errorf("#%d failed: expected the secret to be kept by the update, got %+v %v", i, r, err)
+			}
+		}
+	}
+}
@@ -0,0 +1,106 @@
+package server
+
+import (
+	"context"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates/auth"
+)
+
+// publicPaths can be requested without an API key
+var publicPaths = map[string]bool{
+	"/openapi.json": true,
+	"/metrics":      true,
+	"/healthz":      true,
+	"/readyz":       true,
+	"/status":       true,
+}
+
+type contextKey int
+
+const apiKeyContext contextKey = iota
+
+// SetAuth requires an API key for every request except the ones to public paths, rejecting
+// requests made with keys that exceeded their rate limit or quota
+func (s *Server) SetAuth(m *auth.Manager) {
+	s.keys = m
+}
+
+// SetMetricsAuth sets whether /metrics requires an API key when keys are required. It is public
+// otherwise, so that it can be scraped without one
+func (s *Server) SetMetricsAuth(required bool) {
+	s.metricsAuth = required
+}
+
+// apiKey returns the API key of the request, given by the X-API-Key header, a bearer token
+// or the api_key URL parameter
+func apiKey(req *http.Request) string {
+	if key := req.Header.Get("X-API-Key"); key != "" {
+		return key
+	}
+	if authz := req.Header.Get("Authorization"); strings.HasPrefix(authz, "Bearer ") {
+		return strings.TrimPrefix(authz, "Bearer ")
+	}
+	return req.URL.Query().Get("api_key")
+}
+
+// authenticate checks the API key of requests before handing them to h when keys are required
+func (s *Server) authenticate(h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		public := publicPaths[req.URL.Path] && !(s.metricsAuth && req.URL.Path == "/metrics")
+		if s.keys == nil || public {
+			h.ServeHTTP(w, req)
+			return
+		}
+
+		key := apiKey(req)
+		if key == "" {
+			writeError(w, &apiError{Status: http.StatusUnauthorized, Code: "unauthorized", Message: "missing API key"})
+			return
+		}
+
+		err := s.keys.Allow(key)
+		switch err := err.(type) {
+		case nil:
+		case *auth.LimitError:
+			code := "rate_limited"
+			if err.Quota {
+				code = "quota_exceeded"
+			}
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(err.RetryAfter.Seconds()))))
+			writeError(w, &apiError{Status: http.StatusTooManyRequests, Code: code, Message: err.Error()})
+			return
+		default:
+			writeError(w, &apiError{Status: http.StatusUnauthorized, Code: "unauthorized", Message: err.Error()})
+			return
+		}
+
+		h.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), apiKeyContext, key)))
+	})
+}
+
+type usageJSON struct {
+	Name     string `json:"name"`
+	Requests int64  `json:"requests"`
+	Limited  int64  `json:"limited"`
+	Today    int64  `json:"today"`
+	Quota    int64  `json:"quota,omitempty"`
+}
+
+func (s *Server) v1UsageHandler(w http.ResponseWriter, req *http.Request) {
+	if s.keys == nil {
+		writeError(w, &apiError{Status: http.StatusNotFound, Code: "auth_disabled", Message: "API keys are not enabled"})
+		return
+	}
+
+	key, _ := req.Context().Value(apiKeyContext).(string)
+	u, err := s.keys.Usage(key)
+	if err != nil {
+		writeError(w, &apiError{Status: http.StatusUnauthorized, Code: "unauthorized", Message: err.Error()})
+		return
+	}
+	writeData(w, http.StatusOK, usageJSON{Name: u.Name, Requests: u.Requests, Limited: u.Limited, Today: u.Today, Quota: u.Quota})
+}
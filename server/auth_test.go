@@ -0,0 +1,144 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates/auth"
+)
+
+type keySource []auth.Key
+
+func (s keySource) Keys() ([]auth.Key, error) {
+	return s, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	s := New()
+	m, err := auth.New(keySource{
+		{Key: "k1", Name: "reporting"},
+		{Key: "k2", Name: "limited", Rate: 0.001, Burst: 1},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetAuth(m)
+
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.0, nil
+	}
+
+	var tests = []struct {
+		url               string
+		header            http.Header
+		ExpectedCode      int
+		ExpectedErrorCode string
+		ExpectedRetry     string
+		ExpectedCache     string
+	}{
+		{
+			url:               "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02",
+			ExpectedCode:      http.StatusUnauthorized,
+			ExpectedErrorCode: "unauthorized",
+		},
+		{
+			url:               "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02",
+			header:            http.Header{"X-Api-Key": {"nope"}},
+			ExpectedCode:      http.StatusUnauthorized,
+			ExpectedErrorCode: "unauthorized",
+		},
+		{
+			url:           "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02",
+			header:        http.Header{"X-Api-Key": {"k1"}},
+			ExpectedCode:  http.StatusOK,
+			ExpectedCache: "private, max-age=31536000",
+		},
+		{
+			url:           "/mock?from=USD&to=EUR&date=2017-03-02",
+			header:        http.Header{"Authorization": {"Bearer k1"}},
+			ExpectedCode:  http.StatusOK,
+			ExpectedCache: "private, max-age=31536000",
+		},
+		{
+			url:           "/mock?from=USD&to=EUR&date=2017-03-02&api_key=k2",
+			ExpectedCode:  http.StatusOK,
+			ExpectedCache: "private, max-age=31536000",
+		},
+		{
+			url:               "/mock?from=USD&to=EUR&date=2017-03-02&api_key=k2",
+			ExpectedCode:      http.StatusTooManyRequests,
+			ExpectedErrorCode: "rate_limited",
+			ExpectedRetry:     "1000",
+		},
+		{
+			url:          "/openapi.json",
+			ExpectedCode: http.StatusOK,
+		},
+	}
+
+	for i, tt := range tests {
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = tt.header
+
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v: %s", i, tt.ExpectedCode, rr.Code, rr.Body.String())
+			continue
+		}
+		if retry := rr.Header().Get("Retry-After"); retry != tt.ExpectedRetry {
+			t.Errorf("#%d failed: expected Retry-After=%q, got %q", i, tt.ExpectedRetry, retry)
+		}
+		if cc := rr.Header().Get("Cache-Control"); cc != tt.ExpectedCache {
+			t.Errorf("#%d failed: expected Cache-Control=%q, got %q", i, tt.ExpectedCache, cc)
+		}
+		if tt.ExpectedErrorCode == "" {
+			continue
+		}
+
+		var resp envelope
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.Error == nil || resp.Error.Code != tt.ExpectedErrorCode {
+			t.Errorf("#%d failed: expected error code=%v, got %+v", i, tt.ExpectedErrorCode, resp.Error)
+		}
+	}
+
+	// /metrics is public unless it is required to be scraped with a key
+	s.SetMetricsAuth(true)
+	for key, expected := range map[string]int{"": http.StatusUnauthorized, "k1": http.StatusOK} {
+		req, err := http.NewRequest("GET", "/metrics", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if key != "" {
+			req.Header.Set("X-API-Key", key)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		if rr.Code != expected {
+			t.Errorf("expected /metrics code=%v with key %q, got %v", expected, key, rr.Code)
+		}
+	}
+
+	req, err := http.NewRequest("GET", "/v1/usage", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("X-API-Key", "k1")
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	expected := `{"data":{"name":"reporting","requests":4,"limited":0,"today":4}}` + "\n"
+	if rr.Body.String() != expected {
+		t.Errorf("expected usage %s, got %s", expected, rr.Body.String())
+	}
+}
@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+const (
+	maxBatchQueries    = 10000
+	maxBatchConcurrent = 16
+)
+
+type batchResp struct {
+	Rate  float64 `json:",omitempty"`
+	Error string  `json:",omitempty"`
+}
+
+// batchResult is the outcome of a single query of a batch
+type batchResult struct {
+	exchangerates.Query
+	exchangerates.Result
+}
+
+func batchHandler(w http.ResponseWriter, req *http.Request) {
+	results, err := runBatch(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	resp := make([]batchResp, len(results))
+	for i, res := range results {
+		if res.Err != nil {
+			resp[i].Error = res.Err.Error()
+			continue
+		}
+		resp[i].Rate = res.Rate
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(resp)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// runBatch answers the queries in the request body, in order
+func runBatch(req *http.Request) ([]batchResult, error) {
+	_, store, err := getStoreFormValue(req)
+	if err != nil {
+		return nil, err
+	}
+
+	concurrency := 4
+	if req.FormValue("concurrency") != "" {
+		n, err := strconv.Atoi(req.FormValue("concurrency"))
+		if err != nil || n < 1 || n > maxBatchConcurrent {
+			return nil, invalidParameter(errors.New(`"concurrency" should be between 1 and ` + strconv.Itoa(maxBatchConcurrent)))
+		}
+		concurrency = n
+	}
+
+	var queries []exchangerates.Query
+	err = json.NewDecoder(req.Body).Decode(&queries)
+	if err != nil {
+		return nil, &apiError{Status: http.StatusBadRequest, Code: "invalid_body", Message: "invalid request body: " + err.Error()}
+	}
+	if len(queries) > maxBatchQueries {
+		return nil, &apiError{Status: http.StatusBadRequest, Code: "invalid_body", Message: "too many queries, at most " + strconv.Itoa(maxBatchQueries) + " are allowed"}
+	}
+
+	// queries with invalid dates are answered directly instead of being sent to the store
+	results := make([]batchResult, len(queries))
+	valid := make([]exchangerates.Query, 0, len(queries))
+	index := make([]int, 0, len(queries))
+	fixings := make(map[exchangerates.Query]string) // latest fixings of the periods already resolved
+	for i, q := range queries {
+		results[i].Query = q
+		date, period, err := parseDate(q.Date)
+		if err != nil {
+			results[i].Err = invalidParameter(err)
+			continue
+		}
+		if period {
+			key := exchangerates.Query{From: q.From, To: q.To, Date: date}
+			latest, ok := fixings[key]
+			if !ok {
+				if latest, err = latestFixing(store, q.From, q.To, date, true); err != nil {
+					results[i].Err = lookupFailed(err)
+					continue
+				}
+				fixings[key] = latest
+			}
+			date = latest
+		}
+		q.Date = date
+		results[i].Query = q
+		valid = append(valid, q)
+		index = append(index, i)
+	}
+
+	for i, res := range exchangerates.Batch(store, valid, concurrency) {
+		if res.Err != nil {
+			res.Err = lookupFailed(res.Err)
+		}
+		results[index[i]].Result = res
+	}
+	return results, nil
+}
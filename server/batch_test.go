@@ -0,0 +1,54 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestBatchHandler(t *testing.T) {
+	s := New()
+
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		if to == "XYZ" {
+			return 0, errors.New("currency XYZ not found")
+		}
+		return 1.0, nil
+	}
+
+	body := `[
+		{"from": "USD", "to": "EUR", "date": "2017-03-02"},
+		{"from": "USD", "to": "XYZ", "date": "2017-03-02"},
+		{"from": "USD", "to": "EUR", "date": "20-03-02"}
+	]`
+	req, err := http.NewRequest("POST", "/batch?store=mock", strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected code=%v, got %v: %s", http.StatusOK, rr.Code, rr.Body.String())
+	}
+
+	var resp []batchResp
+	err = json.NewDecoder(rr.Body).Decode(&resp)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	expected := []batchResp{
+		{Rate: 1},
+		{Error: "currency XYZ not found"},
+		{Error: "incorrect date format, should be similar to 2016-03-28"},
+	}
+	if !reflect.DeepEqual(resp, expected) {
+		t.Errorf("expected resp=%v, got %v", expected, resp)
+	}
+}
@@ -0,0 +1,170 @@
+package server
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/gorilla/mux"
+)
+
+var (
+	maxAgeMu       sync.RWMutex
+	historicMaxAge = 365 * 24 * time.Hour // fixings of past dates never change
+	currentMaxAge  = 5 * time.Minute      // today's fixing may not be published yet
+)
+
+// SetCacheMaxAge sets the Cache-Control lifetimes of responses holding fixings of past dates and of
+// responses holding today's fixing. It may be called while the server handles requests
+func SetCacheMaxAge(historic, current time.Duration) {
+	maxAgeMu.Lock()
+	defer maxAgeMu.Unlock()
+	historicMaxAge, currentMaxAge = historic, current
+}
+
+// fixingFunc returns the store answering a request and the date of the newest fixing in its response
+type fixingFunc func(req *http.Request) (exchangerates.Store, time.Time)
+
+// bufferedResponse holds a response so it can be inspected before being sent
+type bufferedResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (b *bufferedResponse) Header() http.Header {
+	return b.header
+}
+
+func (b *bufferedResponse) WriteHeader(code int) {
+	b.code = code
+}
+
+func (b *bufferedResponse) Write(p []byte) (int, error) {
+	return b.body.Write(p)
+}
+
+// cached buffers the successful responses of h to give them a strong ETag computed from their body,
+// a Last-Modified time and a Cache-Control lifetime depending on the date of the fixings they hold.
+// Conditional requests are answered with 304 Not Modified when the response has not changed.
+// The responses of live stores are not to be cached at all, and those to requests made with an API key
+// only by the client's own cache, so that shared caches do not serve them to clients without one
+func cached(fixing fixingFunc, h http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		b := &bufferedResponse{header: w.Header(), code: http.StatusOK}
+		h(b, req)
+
+		w.Header().Add("Vary", "Accept")
+		if b.code != http.StatusOK {
+			w.WriteHeader(b.code)
+			w.Write(b.body.Bytes())
+			return
+		}
+
+		store, date := fixing(req)
+		if isLive(store) {
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(b.code)
+			w.Write(b.body.Bytes())
+			return
+		}
+		sum := sha256.Sum256(b.body.Bytes())
+		w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+		_, keyed := req.Context().Value(apiKeyContext).(string)
+		w.Header().Set("Cache-Control", cacheControl(date, time.Now(), keyed))
+		sw := &statusWriter{ResponseWriter: w}
+		http.ServeContent(sw, req, "", lastModified(store, date, time.Now()), bytes.NewReader(b.body.Bytes()))
+		if sw.code() == http.StatusNotModified {
+			conditionalResponses.Inc("not_modified")
+		} else {
+			conditionalResponses.Inc("full")
+		}
+	}
+}
+
+// cacheControl returns the Cache-Control header of a response holding fixings up to date, private
+// ones being kept by the client's cache only
+func cacheControl(date, now time.Time, private bool) string {
+	maxAgeMu.RLock()
+	maxAge := currentMaxAge
+	if date.Before(now.UTC().Truncate(24 * time.Hour)) {
+		maxAge = historicMaxAge
+	}
+	maxAgeMu.RUnlock()
+	scope := "public"
+	if private {
+		scope = "private"
+	}
+	return scope + ", max-age=" + strconv.Itoa(int(maxAge.Seconds()))
+}
+
+// isLive reports whether the answers of the store may change at any time whatever their date
+func isLive(store exchangerates.Store) bool {
+	if store == nil {
+		return false
+	}
+	l, ok := exchangerates.Unwrap(store).(exchangerates.Live)
+	return ok && l.Live()
+}
+
+// lastModified returns when the data of a response holding fixings up to date last changed: the end of
+// the fixing date once it has passed, as past fixings never change, otherwise when the store's dataset was
+// loaded if it reports it
+func lastModified(store exchangerates.Store, date, now time.Time) time.Time {
+	end := date.AddDate(0, 0, 1)
+	if !end.After(now) {
+		return end
+	}
+	if u, ok := exchangerates.Unwrap(store).(exchangerates.Updater); ok {
+		return u.Updated()
+	}
+	return time.Time{}
+}
+
+// fixingDate returns the date given by the client, yesterday when it is empty. Dates relative to the
+// current day, such as yesterday or -3d, are reported as today's so that their responses are not kept as
+// long as those of past dates, which they stop being answered with the next day
+func fixingDate(value string) time.Time {
+	r, _ := parseDateRange(value, "yesterday")
+	if r.Relative {
+		return time.Now().UTC().Truncate(24 * time.Hour)
+	}
+	return r.End
+}
+
+func rateFixing(req *http.Request) (exchangerates.Store, time.Time) {
+	store, _ := getStore(mux.Vars(req)["store"])
+	return store, fixingDate(req.FormValue("date"))
+}
+
+func seriesFixing(req *http.Request) (exchangerates.Store, time.Time) {
+	store, _ := getStore(mux.Vars(req)["store"])
+	return store, fixingDate(req.FormValue("end"))
+}
+
+func matrixFixing(req *http.Request) (exchangerates.Store, time.Time) {
+	_, store, _ := getStoreFormValue(req)
+	return store, fixingDate(req.FormValue("date"))
+}
+
+func analyticsFixing(req *http.Request) (exchangerates.Store, time.Time) {
+	_, store, _ := getStoreFormValue(req)
+	return store, fixingDate(req.FormValue("end"))
+}
+
+// compareFixing returns the last day of the comparison chart
+func compareFixing(req *http.Request) (exchangerates.Store, time.Time) {
+	_, store, _ := getStoreFormValue(req)
+	return store, fixingDate(req.FormValue("end"))
+}
+
+// chartFixing returns the last day of the month charted
+func chartFixing(req *http.Request) (exchangerates.Store, time.Time) {
+	_, _, month, year, _ := getChartFormValues(nil, req)
+	return stores[defaultStore], time.Date(year, time.Month(month)+1, 0, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/metrics"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+func TestCachedRate(t *testing.T) {
+	s := New()
+
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.0, nil
+	}
+
+	today := time.Now().UTC().Format("2006-01-02")
+	var tests = []struct {
+		url                  string
+		header               http.Header
+		ExpectedCode         int
+		ExpectedCacheControl string
+		ExpectedLastModified string
+	}{
+		{
+			url:                  "/mock?from=USD&to=EUR&date=2017-03-02",
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, max-age=31536000",
+			ExpectedLastModified: "Fri, 03 Mar 2017 00:00:00 GMT",
+		},
+		{
+			url:                  "/v1/stores/mock/rate?from=USD&to=EUR&date=" + today,
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, max-age=300",
+		},
+		{
+			url:                  "/mock?from=USD&to=EUR&date=2017-03-02",
+			header:               http.Header{"If-Modified-Since": {"Sat, 04 Mar 2017 00:00:00 GMT"}},
+			ExpectedCode:         http.StatusNotModified,
+			ExpectedCacheControl: "public, max-age=31536000",
+		},
+		{
+			url:                  "/mock?from=USD&to=EUR&date=2017-03-02",
+			header:               http.Header{"If-Modified-Since": {"Thu, 02 Mar 2017 00:00:00 GMT"}},
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, max-age=31536000",
+			ExpectedLastModified: "Fri, 03 Mar 2017 00:00:00 GMT",
+		},
+		{
+			url:                  "/mock?from=USD&to=EUR&date=-3d",
+			ExpectedCode:         http.StatusOK,
+			ExpectedCacheControl: "public, max-age=300",
+		},
+		{
+			url:          "/mock?from=USD&to=EUR&date=20-03-02",
+			ExpectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for i, tt := range tests {
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header = tt.header
+
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v", i, tt.ExpectedCode, rr.Code)
+		}
+		if cc := rr.Header().Get("Cache-Control"); cc != tt.ExpectedCacheControl {
+			t.Errorf("#%d failed: expected Cache-Control=%q, got %q", i, tt.ExpectedCacheControl, cc)
+		}
+		if lm := rr.Header().Get("Last-Modified"); lm != tt.ExpectedLastModified {
+			t.Errorf("#%d failed: expected Last-Modified=%q, got %q", i, tt.ExpectedLastModified, lm)
+		}
+		if etag := rr.Header().Get("ETag"); (etag != "") != (tt.ExpectedCode != http.StatusBadRequest) {
+			t.Errorf("#%d failed: unexpected ETag %q", i, etag)
+		}
+	}
+}
+
+// updatedStore is a store reporting when its dataset was loaded
+type updatedStore struct {
+	*mock.Store
+	updated time.Time
+}
+
+func (s updatedStore) Updated() time.Time {
+	return s.updated
+}
+
+func TestLastModified(t *testing.T) {
+	now := time.Date(2017, 3, 20, 12, 0, 0, 0, time.UTC)
+	loaded := time.Date(2017, 3, 20, 9, 0, 0, 0, time.UTC)
+	today := time.Date(2017, 3, 20, 0, 0, 0, 0, time.UTC)
+	var tests = []struct {
+		store                exchangerates.Store
+		date                 time.Time
+		ExpectedLastModified time.Time
+	}{
+		{updatedStore{mock.New(), loaded}, time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2017, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{updatedStore{mock.New(), loaded}, today, loaded},
+		{mock.New(), time.Date(2017, 3, 2, 0, 0, 0, 0, time.UTC), time.Date(2017, 3, 3, 0, 0, 0, 0, time.UTC)},
+		{mock.New(), today, time.Time{}},
+	}
+
+	for i, tt := range tests {
+		if lm := lastModified(tt.store, tt.date, now); !lm.Equal(tt.ExpectedLastModified) {
+			t.Errorf("#%d failed: expected %v, got %v", i, tt.ExpectedLastModified, lm)
+		}
+	}
+}
+
+// liveStore is a store answering with the current rate whatever the date requested
+type liveStore struct {
+	*mock.Store
+}
+
+func (s liveStore) Live() bool {
+	return true
+}
+
+func TestCachedLive(t *testing.T) {
+	s := New()
+	live := liveStore{mock.New()}
+	live.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.0, nil
+	}
+	stores["live"] = metrics.Instrument(metrics.Default, "live", live)
+	defer delete(stores, "live")
+
+	for _, url := range []string{"/live?from=USD&to=EUR&date=2017-03-02", "/v1/stores/live/rate?from=USD&to=EUR&date=2017-03-02"} {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusOK {
+			t.Errorf("%s: expected code=200, got %v", url, rr.Code)
+		}
+		if cc := rr.Header().Get("Cache-Control"); cc != "no-store" {
+			t.Errorf("%s: expected Cache-Control=no-store, got %q", url, cc)
+		}
+		if rr.Header().Get("ETag") != "" || rr.Header().Get("Last-Modified") != "" {
+			t.Errorf("%s: expected no validators, got ETag=%q and Last-Modified=%q", url, rr.Header().Get("ETag"), rr.Header().Get("Last-Modified"))
+		}
+	}
+}
+
+func TestCachedETag(t *testing.T) {
+	s := New()
+
+	rate := 1.0
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return rate, nil
+	}
+
+	get := func(etag string) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if etag != "" {
+			req.Header.Set("If-None-Match", etag)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := get("")
+	etag := rr.Header().Get("ETag")
+	if rr.Code != http.StatusOK || len(etag) < 2 || etag[0] != '"' {
+		t.Fatalf("expected code=200 with a strong ETag, got %v and %q", rr.Code, etag)
+	}
+
+	rr = get(etag)
+	if rr.Code != http.StatusNotModified || rr.Body.Len() != 0 {
+		t.Errorf("expected code=304 with no body, got %v: %s", rr.Code, rr.Body.String())
+	}
+
+	rate = 1.5
+	rr = get(etag)
+	if rr.Code != http.StatusOK || rr.Header().Get("ETag") == etag {
+		t.Errorf("expected code=200 with a new ETag once the rate changes, got %v and %q", rr.Code, rr.Header().Get("ETag"))
+	}
+}
@@ -0,0 +1,95 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/analytics"
+)
+
+type analyticsResp struct {
+	*analytics.Summary
+	Correlation *float64 `json:",omitempty"`
+}
+
+func getAnalyticsHandler(w http.ResponseWriter, req *http.Request) {
+	summary, corr, err := getAnalytics(req)
+	if err != nil {
+		legacyError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&analyticsResp{Summary: summary, Correlation: corr})
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// getAnalytics returns the statistics of the series requested and, when another pair
+// is given to compare with, the correlation between the two. Both series are fetched
+// over the same span, capped like that of series at maxSpanYears
+func getAnalytics(req *http.Request) (*analytics.Summary, *float64, error) {
+	_, store, err := getStoreFormValue(req)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	from, to, start, end, err := getSeriesFormValues(nil, req)
+	if err != nil {
+		return nil, nil, invalidParameter(err)
+	}
+
+	window := 20
+	if req.FormValue("window") != "" {
+		window, err = strconv.Atoi(req.FormValue("window"))
+		if err != nil {
+			return nil, nil, invalidParameter(errors.New(`incorrect "window" URL parameter`))
+		}
+	}
+
+	logReturns := true
+	switch req.FormValue("returns") {
+	case "", "log":
+	case "simple":
+		logReturns = false
+	default:
+		return nil, nil, invalidParameter(errors.New(`"returns" should be one of log or simple`))
+	}
+
+	var pair []string
+	if compare := req.FormValue("compare"); compare != "" {
+		pair = strings.Split(compare, "/")
+		if len(pair) != 2 {
+			return nil, nil, invalidParameter(errors.New(`"compare" should be similar to EUR/GBP`))
+		}
+	}
+
+	rates, err := exchangerates.GetRangeExchangeRates(store, from, to, start, end)
+	if err != nil {
+		return nil, nil, lookupFailed(err)
+	}
+
+	summary, err := analytics.Summarize(rates, window, logReturns)
+	if err != nil {
+		return nil, nil, invalidParameter(err)
+	}
+	if pair == nil {
+		return summary, nil, nil
+	}
+
+	other, err := exchangerates.GetRangeExchangeRates(store, pair[0], pair[1], start, end)
+	if err != nil {
+		return nil, nil, lookupFailed(err)
+	}
+	corr, err := analytics.Correlation(rates, other)
+	if err != nil {
+		return nil, nil, invalidParameter(err)
+	}
+	return summary, &corr, nil
+}
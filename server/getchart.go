@@ -1,29 +1,102 @@
 package server
 
 import (
+	"bytes"
 	"errors"
-	"log"
+	"fmt"
 	"net/http"
 	"strconv"
 
 	"github.com/farhan-shahid/exchangerates/chart"
 )
 
-func getChartHandler(w http.ResponseWriter, req *http.Request) {
-	log.Println("serving", req.URL)
+// Limits of the charts drawn by the server, lower than those of the chart package as any client may have
+// one drawn with every request
+const (
+	maxChartSize = 2048
+	maxChartDPI  = 300
+)
 
-	from, to, month, year, err := getChartFormValues(w, req)
+func getChartHandler(w http.ResponseWriter, req *http.Request) {
+	img, format, err := getChart(req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		legacyError(w, err)
 		return
 	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Write(img)
+}
 
-	rates, err := ec.GetMonthExchangeRates(from, to, year, month)
+// getChart returns the chart of the rates of the default store for the month requested, along with its format.
+// The chart is drawn before anything is written so that the rates not making a chart are reported as such
+func getChart(req *http.Request) ([]byte, chart.Format, error) {
+	from, to, month, year, err := getChartFormValues(nil, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, "", invalidParameter(err)
+	}
+	opts, err := getChartOptions(req, chart.GIF)
+	if err != nil {
+		return nil, "", invalidParameter(err)
+	}
+
+	store, err := getStore(defaultStore)
+	if err != nil {
+		return nil, "", err
+	}
+	rates, err := store.GetMonthExchangeRates(from, to, year, month)
+	if err != nil {
+		return nil, "", lookupFailed(err)
+	}
+
+	var b bytes.Buffer
+	if err = chart.MakeRateChartOptions(from, to, rates, &b, opts); err != nil {
+		return nil, "", invalidParameter(err)
+	}
+	return b.Bytes(), opts.Format, nil
+}
+
+// getChartOptions returns how the chart requested should be drawn, in format unless another format is
+// asked for
+func getChartOptions(req *http.Request, format chart.Format) (opts chart.Options, err error) {
+	opts = chart.Options{
+		Format:     format,
+		Theme:      chart.Theme(req.FormValue("theme")),
+		LineColor:  req.FormValue("color"),
+		Title:      req.FormValue("title"),
+		XLabel:     req.FormValue("xlabel"),
+		YLabel:     req.FormValue("ylabel"),
+		DateFormat: req.FormValue("date_format"),
+	}
+	if format := req.FormValue("format"); format != "" {
+		opts.Format = chart.Format(format)
+	}
+	for _, p := range []struct {
+		name  string
+		value *int
+	}{{"width", &opts.Width}, {"height", &opts.Height}} {
+		if v := req.FormValue(p.name); v != "" {
+			if *p.value, err = strconv.Atoi(v); err != nil {
+				return opts, errors.New(`incorrect "` + p.name + `" URL parameter, should be a number of pixels`)
+			}
+		}
+	}
+	if v := req.FormValue("dpi"); v != "" {
+		if opts.DPI, err = strconv.ParseFloat(v, 64); err != nil {
+			return opts, errors.New(`incorrect "dpi" URL parameter`)
+		}
+	}
+	if v := req.FormValue("grid"); v != "" {
+		if opts.Grid, err = strconv.ParseBool(v); err != nil {
+			return opts, errors.New(`incorrect "grid" URL parameter, should be true or false`)
+		}
+	}
+	if opts.Width > maxChartSize || opts.Height > maxChartSize {
+		return opts, fmt.Errorf("the width and height of a chart should not be negative nor above %d pixels", maxChartSize)
+	}
+	if opts.DPI > maxChartDPI {
+		return opts, fmt.Errorf("the DPI of a chart should not be negative nor above %d", maxChartDPI)
 	}
-	chart.MakeRateChartGIF(from, to, rates, w)
+	return opts, opts.Validate()
 }
 
 func getChartFormValues(w http.ResponseWriter, req *http.Request) (from, to string, month, year int, err error) {
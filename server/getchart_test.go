@@ -0,0 +1,89 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func TestGetChartHandler(t *testing.T) {
+	var tests = []struct {
+		query               string
+		ExpectedCode        int
+		ExpectedContentType string
+		ExpectedError       string
+	}{
+		{"", http.StatusOK, "image/gif", ""},
+		{"&format=png&width=400&height=200&dpi=120&theme=dark&color=%23ff8800&grid=1", http.StatusOK, "image/png", ""},
+		{"&format=svg&title=EUR+to+USD&xlabel=date&ylabel=rate&date_format=Jan+2", http.StatusOK, "image/svg+xml", ""},
+		{"&format=jpeg", http.StatusBadRequest, "", `unknown chart format "jpeg", should be one of png, svg or gif`},
+		{"&width=wide", http.StatusBadRequest, "", `incorrect "width" URL parameter, should be a number of pixels`},
+		{"&height=5000", http.StatusBadRequest, "", "the width and height of a chart should not be negative nor above 2048 pixels"},
+		{"&width=3000", http.StatusBadRequest, "", "the width and height of a chart should not be negative nor above 2048 pixels"},
+		{"&dpi=400", http.StatusBadRequest, "", "the DPI of a chart should not be negative nor above 300"},
+		{"&grid=sometimes", http.StatusBadRequest, "", `incorrect "grid" URL parameter, should be true or false`},
+		{"&theme=solarized", http.StatusBadRequest, "", `unknown theme "solarized", should be one of light or dark`},
+	}
+
+	s := New()
+	// charts are drawn from the default store
+	defer func(name string) { defaultStore = name }(defaultStore)
+	defaultStore = "mock"
+	moc.OnGetMonthExchangeRates = func(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+		var rates []exchangerates.DateRate
+		for day := 1; day <= 5; day++ {
+			date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+			rates = append(rates, exchangerates.DateRate{Date: date, Rate: 1 + float64(day)/100})
+		}
+		return rates, nil
+	}
+
+	for i, tt := range tests {
+		req, err := http.NewRequest("GET", "/chart?from=EUR&to=USD&month=3&year=2017"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v", i, tt.ExpectedCode, rr.Code)
+			continue
+		}
+		if rr.Code != http.StatusOK {
+			if strings.TrimSpace(rr.Body.String()) != tt.ExpectedError {
+				t.Errorf("#%d failed: expected error=%q, got %q", i, tt.ExpectedError, rr.Body.String())
+			}
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != tt.ExpectedContentType {
+			t.Errorf("#%d failed: expected Content-Type=%q, got %q", i, tt.ExpectedContentType, ct)
+		}
+	}
+}
+
+func TestGetChartTooFewRates(t *testing.T) {
+	s := New()
+	defer func(name string) { defaultStore = name }(defaultStore)
+	defaultStore = "mock"
+	moc.OnGetMonthExchangeRates = func(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+		return []exchangerates.DateRate{{Date: time.Date(year, time.Month(month), 1, 0, 0, 0, 0, time.UTC), Rate: 1.05}}, nil
+	}
+
+	for _, path := range []string{"/chart", "/v1/chart"} {
+		req, err := http.NewRequest("GET", path+"?from=EUR&to=USD&month=3&year=2017", nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != http.StatusBadRequest || !strings.Contains(rr.Body.String(), "at least two exchange rates are needed to draw a chart") {
+			t.Errorf("%s: expected a single fixing to be reported, got %v: %q", path, rr.Code, rr.Body.String())
+		}
+	}
+}
@@ -0,0 +1,111 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/chart"
+)
+
+func getCompareChartHandler(w http.ResponseWriter, req *http.Request) {
+	img, format, err := getCompareChart(req)
+	if err != nil {
+		legacyError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	w.Write(img)
+}
+
+// getCompareChart returns the chart of the currency pairs requested drawn together, along with its format.
+// The chart is drawn before anything is written so that the rates not making a chart are reported as such
+func getCompareChart(req *http.Request) ([]byte, chart.Format, error) {
+	var opts chart.CompareOptions
+	_, store, err := getStoreFormValue(req)
+	if err != nil {
+		return nil, "", err
+	}
+
+	pairs, secondary, start, end, err := getCompareFormValues(req)
+	if err != nil {
+		return nil, "", invalidParameter(err)
+	}
+	if opts.Options, err = getChartOptions(req, chart.PNG); err != nil {
+		return nil, "", invalidParameter(err)
+	}
+	if v := req.FormValue("rebase"); v != "" {
+		if opts.Rebase, err = strconv.ParseBool(v); err != nil {
+			return nil, "", invalidParameter(errors.New(`incorrect "rebase" URL parameter, should be true or false`))
+		}
+	}
+
+	series := make([]chart.Series, len(pairs))
+	for i, p := range pairs {
+		rates, err := exchangerates.GetRangeExchangeRates(store, p.From, p.To, start, end)
+		if err != nil {
+			return nil, "", lookupFailed(err)
+		}
+		series[i] = chart.Series{Name: p.From + "/" + p.To, Rates: rates, Secondary: secondary[p]}
+	}
+
+	var b bytes.Buffer
+	if err = chart.MakeCompareChart(series, &b, opts); err != nil {
+		return nil, "", invalidParameter(err)
+	}
+	return b.Bytes(), opts.Format, nil
+}
+
+func getCompareFormValues(req *http.Request) (pairs []pair, secondary map[pair]bool, start, end time.Time, err error) {
+	pairs, err = parsePairs(req.FormValue("pairs"))
+	if err != nil {
+		return
+	}
+	if len(pairs) == 0 {
+		err = errors.New(`missing "pairs" URL parameter`)
+		return
+	}
+	if len(pairs) > chart.MaxSeries {
+		err = errors.New(`too many pairs in the "pairs" URL parameter, should be at most ` + strconv.Itoa(chart.MaxSeries))
+		return
+	}
+
+	others, err := parsePairs(req.FormValue("secondary"))
+	if err != nil {
+		return
+	}
+	secondary = make(map[pair]bool)
+	for _, p := range pairs {
+		secondary[p] = false
+	}
+	for _, p := range others {
+		if _, ok := secondary[p]; !ok {
+			err = errors.New("secondary pair " + p.From + "/" + p.To + ` is not one of the "pairs" URL parameter`)
+			return
+		}
+		secondary[p] = true
+	}
+
+	if req.FormValue("start") == "" {
+		err = errors.New(`missing "start" URL parameter`)
+		return
+	}
+	r, err := parseDateRange(req.FormValue("start"), "")
+	if err != nil {
+		err = errors.New(`incorrect start date format, should be similar to 2016-03-28`)
+		return
+	}
+	start = r.Start
+
+	r, err = parseDateRange(req.FormValue("end"), "yesterday")
+	if err != nil {
+		err = errors.New(`incorrect end date format, should be similar to 2016-03-28`)
+		return
+	}
+	end = r.End
+	err = checkSpan(start, end)
+	return
+}
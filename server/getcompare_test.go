@@ -0,0 +1,73 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func TestGetCompareChartHandler(t *testing.T) {
+	var tests = []struct {
+		query               string
+		ExpectedCode        int
+		ExpectedContentType string
+		ExpectedError       string
+	}{
+		{"pairs=EURUSD,EURGBP&start=2017-03-01&end=2017-03-20", http.StatusOK, "image/png", ""},
+		{"pairs=EUR/USD,EUR/JPY&secondary=EURJPY&start=2017-03&end=2017-03-20&rebase=1&format=svg&theme=dark", http.StatusOK, "image/svg+xml", ""},
+		{"start=2017-03-01", http.StatusBadRequest, "", `missing "pairs" URL parameter`},
+		{"pairs=EURUSD,EUR-GBP&start=2017-03-01", http.StatusBadRequest, "", "incorrect pair EUR-GBP, should be similar to EUR/USD"},
+		{"pairs=EURUSD&secondary=EURGBP&start=2017-03-01", http.StatusBadRequest, "", `secondary pair EUR/GBP is not one of the "pairs" URL parameter`},
+		{"pairs=EURUSD", http.StatusBadRequest, "", `missing "start" URL parameter`},
+		{"pairs=EURUSD&start=2017-03-20&end=2017-03-01", http.StatusBadRequest, "", "the end date is before the start date"},
+		{"pairs=EURUSD&start=2010-01-01&end=2017-03-01", http.StatusBadRequest, "", "the start and end dates should be at most 5 years apart"},
+		{"pairs=EURUSD&start=2017-03-01&end=2017-03-20&rebase=maybe", http.StatusBadRequest, "", `incorrect "rebase" URL parameter, should be true or false`},
+		{"pairs=EURUSD&start=2017-03-01&end=2017-03-20&format=gif", http.StatusBadRequest, "", "comparison charts are drawn as PNG or SVG"},
+		{"pairs=EURUSD&start=2017-03-01&end=2017-03-01", http.StatusBadRequest, "", "EUR/USD: at least two exchange rates are needed to draw a chart"},
+		{"pairs=EURUSD,EURGBP,EURJPY,EURCHF,EURCAD,EURAUD,EURNZD,EURSEK,EURNOK&start=2017-03-01", http.StatusBadRequest, "",
+			`too many pairs in the "pairs" URL parameter, should be at most 8`},
+	}
+
+	s := New()
+	defer func(name string) { defaultStore = name }(defaultStore)
+	defaultStore = "mock"
+	moc.OnGetMonthExchangeRates = func(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+		var rates []exchangerates.DateRate
+		for day := 1; day <= 28; day++ {
+			date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+			rate := 1 + float64(day)/100
+			if to == "JPY" {
+				rate *= 120
+			}
+			rates = append(rates, exchangerates.DateRate{Date: date, Rate: rate})
+		}
+		return rates, nil
+	}
+
+	for i, tt := range tests {
+		req, err := http.NewRequest("GET", "/chart/compare?"+tt.query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v: %s", i, tt.ExpectedCode, rr.Code, rr.Body.String())
+			continue
+		}
+		if rr.Code != http.StatusOK {
+			if strings.TrimSpace(rr.Body.String()) != tt.ExpectedError {
+				t.Errorf("#%d failed: expected error=%q, got %q", i, tt.ExpectedError, rr.Body.String())
+			}
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != tt.ExpectedContentType {
+			t.Errorf("#%d failed: expected Content-Type=%q, got %q", i, tt.ExpectedContentType, ct)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/farhan-shahid/exchangerates/crossrate"
+)
+
+type matrixResp struct {
+	Currencies []string
+	Rates      [][]float64
+}
+
+// matrixResult holds the exchange rates between every pair of currencies
+type matrixResult struct {
+	Store      string      `json:"store"`
+	Date       string      `json:"date"`
+	Currencies []string    `json:"currencies"`
+	Rates      [][]float64 `json:"rates"`
+	Source     string      `json:"source"` // crossrate.FromQuotes or crossrate.FromRates, the latter being rounded
+}
+
+func getMatrixHandler(w http.ResponseWriter, req *http.Request) {
+	res, err := getMatrix(req)
+	if err != nil {
+		legacyError(w, err)
+		return
+	}
+
+	if responseFormat(req) == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		err = writeMatrixCSV(w, res.Currencies, res.Rates)
+	} else {
+		w.Header().Set("Content-Type", "application/json")
+		err = json.NewEncoder(w).Encode(&matrixResp{Currencies: res.Currencies, Rates: res.Rates})
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+func getMatrix(req *http.Request) (*matrixResult, error) {
+	storename, store, err := getStoreFormValue(req)
+	if err != nil {
+		return nil, err
+	}
+
+	currencies, date, period, err := getMatrixFormValues(nil, req)
+	if err != nil {
+		return nil, invalidParameter(err)
+	}
+	if len(currencies) > 1 {
+		if date, err = latestFixing(store, currencies[0], currencies[1], date, period); err != nil {
+			return nil, lookupFailed(err)
+		}
+	}
+
+	rates, source, err := crossrate.MatrixSource(store, currencies, date)
+	if err != nil {
+		return nil, lookupFailed(err)
+	}
+	return &matrixResult{Store: storename, Date: date, Currencies: currencies, Rates: rates, Source: source}, nil
+}
+
+func getMatrixFormValues(w http.ResponseWriter, req *http.Request) (currencies []string, date string, period bool, err error) {
+	if req.FormValue("currencies") == "" {
+		err = errors.New(`missing "currencies" URL parameter`)
+		return
+	}
+	currencies = strings.Split(req.FormValue("currencies"), ",")
+
+	date, period, err = getDateFormValue(req)
+	return
+}
+
+// responseFormat returns the output format requested by the client using the format parameter
+// or the Accept header. It is one of json, csv or ndjson
+func responseFormat(req *http.Request) string {
+	switch format := req.FormValue("format"); format {
+	case "json", "csv", "ndjson":
+		return format
+	}
+	accept := req.Header.Get("Accept")
+	if strings.Contains(accept, "text/csv") {
+		return "csv"
+	}
+	if strings.Contains(accept, "application/x-ndjson") {
+		return "ndjson"
+	}
+	return "json"
+}
+
+func writeMatrixCSV(w http.ResponseWriter, currencies []string, rates [][]float64) error {
+	csvWriter := csv.NewWriter(w)
+	err := csvWriter.Write(append([]string{""}, currencies...))
+	if err != nil {
+		return err
+	}
+	for i, row := range rates {
+		record := []string{currencies[i]}
+		for _, rate := range row {
+			record = append(record, formatRate(rate))
+		}
+		err = csvWriter.Write(record)
+		if err != nil {
+			return err
+		}
+	}
+	csvWriter.Flush()
+	return csvWriter.Error()
+}
@@ -0,0 +1,107 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func TestGetMatrixHandler(t *testing.T) {
+	var tests = []struct {
+		query         string
+		quotes        bool
+		ExpectedCode  int
+		ExpectedResp  matrixResp
+		ExpectedBody  string
+		ExpectedError string
+	}{
+		{
+			query:        "currencies=EUR,USD,GBP&date=2017-03-02",
+			quotes:       true,
+			ExpectedCode: http.StatusOK,
+			ExpectedResp: matrixResp{Currencies: []string{"EUR", "USD", "GBP"}, Rates: [][]float64{{1, 1.05, 0.85}, {0.95238, 1, 0.80952}, {1.17647, 1.23529, 1}}},
+		},
+		{
+			query:        "currencies=EUR,USD&date=2017-03-02",
+			ExpectedCode: http.StatusOK,
+			ExpectedResp: matrixResp{Currencies: []string{"EUR", "USD"}, Rates: [][]float64{{1, 2}, {0.5, 1}}},
+		},
+		{
+			query:        "currencies=EUR,USD&date=2017-03-02&format=csv",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: ",EUR,USD\nEUR,1,2\nUSD,0.5,1\n",
+		},
+		{
+			query:         "date=2017-03-02",
+			ExpectedCode:  http.StatusBadRequest,
+			ExpectedError: `missing "currencies" URL parameter`,
+		},
+		{
+			query:         "currencies=EUR,USD&date=20-03-02",
+			ExpectedCode:  http.StatusBadRequest,
+			ExpectedError: "incorrect date format, should be similar to 2016-03-28",
+		},
+		{
+			query:         "currencies=EUR,USD&date=2017-03-02&store=nosuchstore",
+			ExpectedCode:  http.StatusNotFound,
+			ExpectedError: "nosuchstore is not a valid store",
+		},
+	}
+
+	s := New()
+	defer func() { moc.OnGetQuotes = nil }()
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		if to == "USD" {
+			return 2, nil
+		}
+		return 1, nil
+	}
+
+	for i, tt := range tests {
+		moc.OnGetQuotes = nil
+		if tt.quotes {
+			moc.OnGetQuotes = func(date string) ([]exchangerates.Quote, error) {
+				return []exchangerates.Quote{{From: "EUR", To: "USD", Rate: 1.05}, {From: "EUR", To: "GBP", Rate: 0.85}}, nil
+			}
+		}
+		query := tt.query
+		if !strings.Contains(query, "store=") {
+			query += "&store=mock"
+		}
+		req, err := http.NewRequest("GET", "/matrix?"+query, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v: %s", i, tt.ExpectedCode, rr.Code, rr.Body.String())
+			continue
+		}
+		switch {
+		case rr.Code != http.StatusOK:
+			if strings.TrimSpace(rr.Body.String()) != tt.ExpectedError {
+				t.Errorf("#%d failed: expected error=%q, got %q", i, tt.ExpectedError, rr.Body.String())
+			}
+		case tt.ExpectedBody != "":
+			if rr.Body.String() != tt.ExpectedBody {
+				t.Errorf("#%d failed: expected body=%q, got %q", i, tt.ExpectedBody, rr.Body.String())
+			}
+		default:
+			var resp matrixResp
+			if err := json.NewDecoder(rr.Body).Decode(&resp); err != nil {
+				t.Errorf("#%d failed: %v", i, err)
+				continue
+			}
+			if !reflect.DeepEqual(resp, tt.ExpectedResp) {
+				t.Errorf("#%d failed: expected %v, got %v", i, tt.ExpectedResp, resp)
+			}
+		}
+	}
+}
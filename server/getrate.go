@@ -3,53 +3,95 @@ package server
 import (
 	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
+	"strings"
 	"time"
 
 	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/crossrate"
+	"github.com/farhan-shahid/exchangerates/dateexpr"
+	"github.com/farhan-shahid/exchangerates/metrics"
 	"github.com/gorilla/mux"
 )
 
+// periodLookback is how many days are searched back from the last day of a period for its last fixing
+const periodLookback = 10
+
+// rateResult is an exchange rate along with the query it answers
+type rateResult struct {
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Date  string   `json:"date"`
+	Store string   `json:"store"`
+	Rate  float64  `json:"rate"`
+	Path  []string `json:"path,omitempty"`
+}
+
 func getRateHandler(w http.ResponseWriter, req *http.Request) {
-	log.Println("serving", req.URL)
-
-	var store exchangerates.Store
-
-	vars := mux.Vars(req)
-	if vars["store"] == "ecb" {
-		store = ec
-	} else if vars["store"] == "googlefinance" {
-		store = goog
-	} else if vars["store"] == "ecbsql" {
-		store = ecsql
-	} else if vars["store"] == "mock" {
-		store = moc
-	} else {
-		http.Error(w, vars["store"]+" is not a valid store", http.StatusBadRequest)
+	res, err := getRate(mux.Vars(req)["store"], req)
+	if err != nil {
+		legacyError(w, err)
 		return
 	}
 
-	from, to, date, err := getRateFormValues(w, req)
+	w.Header().Set("Content-Type", "application/json")
+	err = json.NewEncoder(w).Encode(&rateResp{Rate: res.Rate, Path: res.Path})
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
+		http.Error(w, err.Error(), http.StatusInternalServerError)
 		return
 	}
+}
 
-	rate, err := store.GetExchangeRate(from, to, date)
+func getRate(storename string, req *http.Request) (*rateResult, error) {
+	store, err := getStore(storename)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusBadRequest)
-		return
+		return nil, err
 	}
 
-	err = json.NewEncoder(w).Encode(&rateResp{Rate: rate})
+	from, to, date, period, err := getRateFormValues(nil, req)
 	if err != nil {
-		http.Error(w, err.Error(), http.StatusInternalServerError)
-		return
+		return nil, invalidParameter(err)
+	}
+	if date, err = latestFixing(store, from, to, date, period); err != nil {
+		return nil, lookupFailed(err)
+	}
+
+	if cs, ok := exchangerates.Unwrap(store).(*crossrate.Store); ok && req.FormValue("pivots") != "" {
+		store = metrics.Instrument(metrics.Default, storename, cs.WithPivots(strings.Split(req.FormValue("pivots"), ",")))
+	}
+
+	res := &rateResult{From: from, To: to, Date: date, Store: storename}
+	if ps, ok := store.(exchangerates.PathStore); ok {
+		res.Rate, res.Path, err = ps.GetExchangeRatePath(from, to, date)
+	} else {
+		res.Rate, err = store.GetExchangeRate(from, to, date)
+	}
+	if err != nil {
+		return nil, lookupFailed(err)
+	}
+	return res, nil
+}
+
+// getStore returns the store with the given name
+func getStore(name string) (exchangerates.Store, error) {
+	store, ok := stores[name]
+	if !ok {
+		return nil, &apiError{Status: http.StatusNotFound, Code: "unknown_store", Message: name + " is not a valid store"}
 	}
+	return store, nil
 }
 
-func getRateFormValues(w http.ResponseWriter, req *http.Request) (from, to, date string, err error) {
+// getStoreFormValue returns the store named by the store URL parameter, the default store when it is missing
+func getStoreFormValue(req *http.Request) (string, exchangerates.Store, error) {
+	name := req.FormValue("store")
+	if name == "" {
+		name = defaultStore
+	}
+	store, err := getStore(name)
+	return name, store, err
+}
+
+func getRateFormValues(w http.ResponseWriter, req *http.Request) (from, to, date string, period bool, err error) {
 	from = req.FormValue("from")
 	if from == "" {
 		err = errors.New(`missing "from" URL parameter`)
@@ -62,15 +104,48 @@ func getRateFormValues(w http.ResponseWriter, req *http.Request) (from, to, date
 		return
 	}
 
-	date = time.Now().AddDate(0, 0, -1).UTC().Format("2006-01-02") // default date is yesterday's date
-	if req.FormValue("date") != "" {
-		_, err = time.Parse("2006-01-02", req.FormValue("date"))
-		if err == nil {
-			date = req.FormValue("date")
-		} else {
-			err = errors.New(`incorrect date format, should be similar to 2016-03-28`)
-			return
-		}
-	}
+	date, period, err = getDateFormValue(req)
 	return
 }
+
+func getDateFormValue(req *http.Request) (date string, period bool, err error) {
+	return parseDate(req.FormValue("date"))
+}
+
+// parseDate resolves the date given by the client, which may be an expression such as -3d or 2017-03 as
+// understood by dateexpr, to the day it names, the last one of periods, reporting whether it names a period.
+// It defaults to yesterday's date when empty
+func parseDate(value string) (date string, period bool, err error) {
+	r, err := parseDateRange(value, "yesterday")
+	if err != nil {
+		return "", false, errors.New(`incorrect date format, should be similar to 2016-03-28`)
+	}
+	return r.End.Format("2006-01-02"), r.Period, nil
+}
+
+// latestFixing returns the date of the latest fixing of from/to on or before date when date is the last day
+// of a period, which may fall on a weekend or a holiday, and date itself otherwise. When no recent fixing is
+// found date is returned, for the lookup to report the rate as missing
+func latestFixing(store exchangerates.Store, from, to, date string, period bool) (string, error) {
+	if !period {
+		return date, nil
+	}
+	end, err := time.Parse("2006-01-02", date)
+	if err != nil {
+		return "", err
+	}
+	latest, _, err := exchangerates.GetLatestExchangeRate(store, from, to, end, periodLookback)
+	if exchangerates.IsNotFound(err) {
+		return date, nil
+	}
+	return latest, err
+}
+
+// parseDateRange resolves a date expression given by the client, or def when it is empty, relative to the
+// current day in UTC
+func parseDateRange(value, def string) (dateexpr.Range, error) {
+	if value == "" {
+		value = def
+	}
+	return dateexpr.Parse(value, time.Now().UTC())
+}
@@ -2,12 +2,16 @@ package server
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"reflect"
 	"strings"
 	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
 )
 
 func TestGetRateHandler(t *testing.T) {
@@ -41,11 +45,29 @@ func TestGetRateHandler(t *testing.T) {
 			ExpectedResp:  rateResp{},
 			ExpectedError: `missing "to" URL parameter`,
 		},
+		{
+			params:        url.Values{"from": {"USD"}, "to": {"XYZ"}, "date": {"2017-03-02"}},
+			ExpectedCode:  http.StatusNotFound,
+			ExpectedResp:  rateResp{},
+			ExpectedError: "currency XYZ not found",
+		},
+		{
+			params:        url.Values{"from": {"USD"}, "to": {"ERR"}, "date": {"2017-03-02"}},
+			ExpectedCode:  http.StatusBadGateway,
+			ExpectedResp:  rateResp{},
+			ExpectedError: "connection refused",
+		},
 	}
 
 	s := New()
 
 	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		switch to {
+		case "XYZ":
+			return 0, exchangerates.NotFound("currency XYZ not found")
+		case "ERR":
+			return 0, errors.New("connection refused")
+		}
 		return 1.0, nil
 	}
 
@@ -80,3 +102,73 @@ func TestGetRateHandler(t *testing.T) {
 		}
 	}
 }
+
+func TestGetRateUnknownStore(t *testing.T) {
+	req, err := http.NewRequest("GET", "/ecbb?from=USD&to=EUR&date=2017-03-02", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	rr := httptest.NewRecorder()
+	New().ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusNotFound || strings.TrimSpace(rr.Body.String()) != "ecbb is not a valid store" {
+		t.Errorf("expected 404 ecbb is not a valid store, got %v %q", rr.Code, rr.Body.String())
+	}
+}
+
+func TestParseDate(t *testing.T) {
+	yesterday := time.Now().UTC().AddDate(0, 0, -1).Format("2006-01-02")
+	var tests = []struct {
+		value, Expected string
+		ExpectedPeriod  bool
+	}{
+		{"2017-03-02", "2017-03-02", false},
+		{"", yesterday, false},
+		{"yesterday UTC", yesterday, false},
+		{"2017-02", "2017-02-28", true},
+		{"end of 2016-Q4", "2016-12-31", true},
+	}
+
+	for _, tt := range tests {
+		if date, period, err := parseDate(tt.value); err != nil || date != tt.Expected || period != tt.ExpectedPeriod {
+			t.Errorf("%q: expected %s, period=%v, got %s, period=%v, %v", tt.value, tt.Expected, tt.ExpectedPeriod, date, period, err)
+		}
+	}
+}
+
+func TestGetRatePeriod(t *testing.T) {
+	// rates are fixed on weekdays only, April 2017 ending on a Sunday
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		if d, _ := time.Parse("2006-01-02", date); d.Weekday() == time.Saturday || d.Weekday() == time.Sunday {
+			return 0, exchangerates.NotFound("date not found")
+		}
+		return 1.0, nil
+	}
+
+	s := New()
+	for value, expected := range map[string]string{
+		"2017-04":        "2017-04-28",
+		"end of 2017-04": "2017-04-28",
+		"2017-04-30":     "",
+		"2017-04-28":     "2017-04-28",
+	} {
+		req, err := http.NewRequest("GET", "/v1/stores/mock/rate?from=USD&to=EUR&date="+url.QueryEscape(value), nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if expected == "" {
+			if rr.Code != http.StatusNotFound {
+				t.Errorf("%q: expected code=%v, got %v", value, http.StatusNotFound, rr.Code)
+			}
+			continue
+		}
+		var resp struct{ Data rateResult }
+		if err = json.NewDecoder(rr.Body).Decode(&resp); err != nil || resp.Data.Date != expected {
+			t.Errorf("%q: expected the fixing of %s, got %+v (%v)", value, expected, resp.Data, err)
+		}
+	}
+}
@@ -0,0 +1,176 @@
+package server
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/series"
+	"github.com/gorilla/mux"
+)
+
+// maxSpanYears is the longest span of series, analytics and comparison charts, whose months are all fetched
+// from the store for every request
+const maxSpanYears = 5
+
+func getSeriesHandler(w http.ResponseWriter, req *http.Request) {
+	rates, bars, err := getSeries(mux.Vars(req)["store"], req)
+	if err != nil {
+		legacyError(w, err)
+		return
+	}
+
+	format := responseFormat(req)
+	if bars != nil {
+		err = writeBars(w, format, bars)
+	} else {
+		err = writeRates(w, format, rates)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+}
+
+// getSeries returns the resampled series requested, as OHLC bars when the method is ohlc
+func getSeries(storename string, req *http.Request) ([]exchangerates.DateRate, []series.OHLC, error) {
+	store, err := getStore(storename)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	from, to, start, end, err := getSeriesFormValues(nil, req)
+	if err != nil {
+		return nil, nil, invalidParameter(err)
+	}
+
+	period, err := series.ParsePeriod(req.FormValue("period"))
+	if err != nil {
+		return nil, nil, invalidParameter(err)
+	}
+
+	fill := req.FormValue("fill")
+	if fill != "" && fill != "days" && fill != "weekdays" {
+		return nil, nil, invalidParameter(errors.New(`"fill" should be one of days or weekdays`))
+	}
+
+	rates, err := exchangerates.GetRangeExchangeRates(store, from, to, start, end)
+	if err != nil {
+		return nil, nil, lookupFailed(err)
+	}
+
+	if fill != "" {
+		rates = series.FillForward(rates, series.Days(start, end, fill == "weekdays"))
+	}
+
+	if req.FormValue("method") == "ohlc" {
+		return nil, series.Bars(rates, period), nil
+	}
+	rates, err = series.Resample(rates, period, req.FormValue("method"))
+	if err != nil {
+		return nil, nil, invalidParameter(err)
+	}
+	return rates, nil, nil
+}
+
+func getSeriesFormValues(w http.ResponseWriter, req *http.Request) (from, to string, start, end time.Time, err error) {
+	from = req.FormValue("from")
+	if from == "" {
+		err = errors.New(`missing "from" URL parameter`)
+		return
+	}
+
+	to = req.FormValue("to")
+	if to == "" {
+		err = errors.New(`missing "to" URL parameter`)
+		return
+	}
+
+	if req.FormValue("start") == "" {
+		err = errors.New(`missing "start" URL parameter`)
+		return
+	}
+	r, err := parseDateRange(req.FormValue("start"), "")
+	if err != nil {
+		err = errors.New(`incorrect start date format, should be similar to 2016-03-28`)
+		return
+	}
+	start = r.Start
+
+	r, err = parseDateRange(req.FormValue("end"), "yesterday")
+	if err != nil {
+		err = errors.New(`incorrect end date format, should be similar to 2016-03-28`)
+		return
+	}
+	end = r.End
+	err = checkSpan(start, end)
+	return
+}
+
+// checkSpan checks that a series ends after it starts and within maxSpanYears
+func checkSpan(start, end time.Time) error {
+	switch {
+	case end.Before(start):
+		return errors.New("the end date is before the start date")
+	case end.After(start.AddDate(maxSpanYears, 0, 0)):
+		return errors.New("the start and end dates should be at most " + strconv.Itoa(maxSpanYears) + " years apart")
+	}
+	return nil
+}
+
+func writeRates(w http.ResponseWriter, format string, rates []exchangerates.DateRate) error {
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		records := [][]string{{"Date", "Rate"}}
+		for _, r := range rates {
+			records = append(records, []string{r.Date.Format("2006-01-02"), formatRate(r.Rate)})
+		}
+		return csv.NewWriter(w).WriteAll(records)
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		for _, r := range rates {
+			err := enc.Encode(r)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(rates)
+}
+
+func writeBars(w http.ResponseWriter, format string, bars []series.OHLC) error {
+	switch format {
+	case "csv":
+		w.Header().Set("Content-Type", "text/csv")
+		records := [][]string{{"Date", "Open", "High", "Low", "Close"}}
+		for _, b := range bars {
+			records = append(records, []string{b.Date.Format("2006-01-02"),
+				formatRate(b.Open), formatRate(b.High), formatRate(b.Low), formatRate(b.Close)})
+		}
+		return csv.NewWriter(w).WriteAll(records)
+	case "ndjson":
+		w.Header().Set("Content-Type", "application/x-ndjson")
+		enc := json.NewEncoder(w)
+		for _, b := range bars {
+			err := enc.Encode(b)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	w.Header().Set("Content-Type", "application/json")
+	return json.NewEncoder(w).Encode(bars)
+}
+
+func formatRate(rate float64) string {
+	return strconv.FormatFloat(rate, 'f', -1, 64)
+}
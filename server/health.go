@@ -0,0 +1,114 @@
+package server
+
+import (
+	"encoding/json"
+	"net/http"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// SetReadiness makes /readyz report the server as ready only once each of the named stores has
+// loaded its dataset and, for stores reporting when it was loaded, did so within maxAge.
+// A zero maxAge disables the age check. It may be called while the server handles requests
+func (s *Server) SetReadiness(stores []string, maxAge time.Duration) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.readyStores = stores
+	s.maxAge = maxAge
+}
+
+type storeStatusJSON struct {
+	Name    string     `json:"name"`
+	Updated *time.Time `json:"updated,omitempty"`
+	Latest  string     `json:"latest,omitempty"`
+	Rows    int        `json:"rows"`
+	Error   string     `json:"error,omitempty"`
+}
+
+func toStoreStatusJSON(name string, status exchangerates.Status) storeStatusJSON {
+	res := storeStatusJSON{Name: name, Latest: status.Latest, Rows: status.Rows}
+	if !status.Updated.IsZero() {
+		res.Updated = &status.Updated
+	}
+	if status.Err != nil {
+		res.Error = status.Err.Error()
+	}
+	return res
+}
+
+// readiness returns a problem with the dataset of the named store, or ok
+func (s *Server) readiness(name string, now time.Time) string {
+	store, ok := stores[name]
+	if !ok {
+		return "unknown store"
+	}
+	sr, ok := exchangerates.Unwrap(store).(exchangerates.StatusReporter)
+	if !ok {
+		return "ok"
+	}
+
+	s.mu.RLock()
+	maxAge := s.maxAge
+	s.mu.RUnlock()
+
+	status := sr.Status()
+	if status.Rows == 0 {
+		if status.Err != nil {
+			return "no data loaded: " + status.Err.Error()
+		}
+		return "no data loaded"
+	}
+	if age := now.Sub(status.Updated); maxAge > 0 && !status.Updated.IsZero() && age > maxAge {
+		return "data loaded " + age.Truncate(time.Second).String() + " ago"
+	}
+	return "ok"
+}
+
+func writeHealthJSON(w http.ResponseWriter, code int, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// healthzHandler reports that the process is alive
+func healthzHandler(w http.ResponseWriter, req *http.Request) {
+	writeHealthJSON(w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+// readyzHandler reports whether the stores the server depends on hold fresh data
+func (s *Server) readyzHandler(w http.ResponseWriter, req *http.Request) {
+	s.mu.RLock()
+	readyStores := s.readyStores
+	s.mu.RUnlock()
+
+	ready := true
+	checks := map[string]string{}
+	for _, name := range readyStores {
+		checks[name] = s.readiness(name, time.Now())
+		ready = ready && checks[name] == "ok"
+	}
+
+	code := http.StatusOK
+	if !ready {
+		code = http.StatusServiceUnavailable
+	}
+	writeHealthJSON(w, code, struct {
+		Ready  bool              `json:"ready"`
+		Stores map[string]string `json:"stores"`
+	}{ready, checks})
+}
+
+// statusHandler describes the dataset of every store that reports it
+func statusHandler(w http.ResponseWriter, req *http.Request) {
+	statuses := []storeStatusJSON{}
+	for _, name := range StoreNames() {
+		if sr, ok := exchangerates.Unwrap(stores[name]).(exchangerates.StatusReporter); ok {
+			statuses = append(statuses, toStoreStatusJSON(name, sr.Status()))
+		}
+	}
+	writeHealthJSON(w, http.StatusOK, struct {
+		Stores []storeStatusJSON `json:"stores"`
+	}{statuses})
+}
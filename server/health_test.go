@@ -0,0 +1,90 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+// statusStore is a store reporting a fixed status
+type statusStore struct {
+	*mock.Store
+	status exchangerates.Status
+}
+
+func (s *statusStore) Status() exchangerates.Status {
+	return s.status
+}
+
+func TestHealth(t *testing.T) {
+	loaded := &statusStore{Store: mock.New(), status: exchangerates.Status{Updated: time.Now().Add(-time.Minute), Latest: "2017-03-02", Rows: 10}}
+	stale := &statusStore{Store: mock.New(), status: exchangerates.Status{Updated: time.Now().Add(-2 * time.Hour), Latest: "2017-03-01", Rows: 10}}
+	failed := &statusStore{Store: mock.New(), status: exchangerates.Status{Err: errors.New("connection refused")}}
+	stores["loaded"], stores["stale"], stores["failed"] = loaded, stale, failed
+	defer func() {
+		delete(stores, "loaded")
+		delete(stores, "stale")
+		delete(stores, "failed")
+	}()
+
+	var tests = []struct {
+		url          string
+		ready        []string
+		ExpectedCode int
+		ExpectedBody []string
+	}{
+		{
+			url:          "/healthz",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: []string{`{"status":"ok"}`},
+		},
+		{
+			url:          "/readyz",
+			ready:        []string{"loaded", "mock"},
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: []string{`{"ready":true,"stores":{"loaded":"ok","mock":"ok"}}`},
+		},
+		{
+			url:          "/readyz",
+			ready:        []string{"loaded", "stale", "failed"},
+			ExpectedCode: http.StatusServiceUnavailable,
+			ExpectedBody: []string{`"ready":false`, `"failed":"no data loaded: connection refused"`, `"stale":"data loaded 2h0m0s ago"`},
+		},
+		{
+			url:          "/status",
+			ExpectedCode: http.StatusOK,
+			ExpectedBody: []string{
+				`{"name":"failed","rows":0,"error":"connection refused"}`,
+				`"latest":"2017-03-02","rows":10}`,
+				`{"name":"loaded","updated":"`,
+			},
+		},
+	}
+
+	for i, tt := range tests {
+		s := New()
+		s.SetReadiness(tt.ready, time.Hour)
+
+		req, err := http.NewRequest("GET", tt.url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v", i, tt.ExpectedCode, rr.Code)
+		}
+		for _, part := range tt.ExpectedBody {
+			if !strings.Contains(rr.Body.String(), part) {
+				t.Errorf("#%d failed: expected body to contain %s, got %s", i, part, rr.Body.String())
+			}
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package server
+
+import (
+	"bufio"
+	"database/sql"
+	"errors"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/metrics"
+	"github.com/gorilla/mux"
+)
+
+var (
+	httpRequests         = metrics.Default.Counter("exchangerates_http_requests_total", "HTTP requests served by route, method and status.", "route", "method", "status")
+	httpDuration         = metrics.Default.Histogram("exchangerates_http_request_duration_seconds", "Latency of HTTP requests by route and method.", metrics.DefaultBuckets, "route", "method")
+	conditionalResponses = metrics.Default.Counter("exchangerates_http_conditional_responses_total", "Cacheable responses answered with 304 Not Modified (not_modified) or sent in full (full).", "result")
+)
+
+func init() {
+	metrics.Default.GaugeFunc("exchangerates_dataset_age_seconds", "Time since the dataset of a store was loaded.", []string{"store"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, name := range StoreNames() {
+			if u, ok := exchangerates.Unwrap(stores[name]).(exchangerates.Updater); ok && !u.Updated().IsZero() {
+				samples = append(samples, metrics.Sample{Labels: []string{name}, Value: time.Since(u.Updated()).Seconds()})
+			}
+		}
+		return samples
+	})
+	metrics.Default.GaugeFunc("exchangerates_dataset_rows", "Number of dates held in the dataset of a store.", []string{"store"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for _, name := range StoreNames() {
+			if sr, ok := exchangerates.Unwrap(stores[name]).(exchangerates.StatusReporter); ok {
+				samples = append(samples, metrics.Sample{Labels: []string{name}, Value: float64(sr.Status().Rows)})
+			}
+		}
+		return samples
+	})
+	metrics.Default.GaugeFunc("exchangerates_sql_connections", "Connections of the SQL pool of a store by state.", []string{"store", "state"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for name, stats := range sqlStats() {
+			samples = append(samples,
+				metrics.Sample{Labels: []string{name, "open"}, Value: float64(stats.OpenConnections)},
+				metrics.Sample{Labels: []string{name, "in_use"}, Value: float64(stats.InUse)},
+				metrics.Sample{Labels: []string{name, "idle"}, Value: float64(stats.Idle)})
+		}
+		return samples
+	})
+	metrics.Default.CounterFunc("exchangerates_sql_waits_total", "Connections of the SQL pool of a store that had to be waited for.", []string{"store"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for name, stats := range sqlStats() {
+			samples = append(samples, metrics.Sample{Labels: []string{name}, Value: float64(stats.WaitCount)})
+		}
+		return samples
+	})
+	metrics.Default.CounterFunc("exchangerates_sql_wait_seconds_total", "Time spent waiting for connections of the SQL pool of a store.", []string{"store"}, func() []metrics.Sample {
+		var samples []metrics.Sample
+		for name, stats := range sqlStats() {
+			samples = append(samples, metrics.Sample{Labels: []string{name}, Value: stats.WaitDuration.Seconds()})
+		}
+		return samples
+	})
+}
+
+// sqlStats returns the connection pool statistics of the stores backed by a database
+func sqlStats() map[string]sql.DBStats {
+	stats := map[string]sql.DBStats{}
+	for name, s := range stores {
+		if st, ok := exchangerates.Unwrap(s).(interface{ Stats() sql.DBStats }); ok {
+			stats[name] = st.Stats()
+		}
+	}
+	return stats
+}
+
+// statusWriter records the status code and size of a response. It supports flushing and
+// hijacking when the underlying ResponseWriter does so streams keep working
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+	size   int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	if w.status == 0 {
+		w.status = code
+	}
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.size += n
+	return n, err
+}
+
+func (w *statusWriter) Flush() {
+	if f, ok := w.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (w *statusWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := w.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, errors.New("response does not support hijacking")
+	}
+	if w.status == 0 {
+		w.status = http.StatusSwitchingProtocols
+	}
+	return h.Hijack()
+}
+
+// Unwrap returns the underlying ResponseWriter for http.ResponseController
+func (w *statusWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// code returns the status of the response, 200 if nothing was written
+func (w *statusWriter) code() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// routeTemplate returns the path template of the route matching the request so metrics are
+// not labelled with every URL requested
+func routeTemplate(router *mux.Router, req *http.Request) string {
+	var match mux.RouteMatch
+	if !router.Match(req, &match) || match.Route == nil {
+		return "unmatched"
+	}
+	tmpl, err := match.Route.GetPathTemplate()
+	if err != nil {
+		return "unmatched"
+	}
+	return tmpl
+}
+
+// instrument records the count and latency of the requests handled by h by route
+func instrument(router *mux.Router, h http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		start := time.Now()
+		route := routeTemplate(router, req)
+		sw := &statusWriter{ResponseWriter: w}
+		h.ServeHTTP(sw, req)
+		httpRequests.Inc(route, req.Method, strconv.Itoa(sw.code()))
+		httpDuration.Observe(time.Since(start).Seconds(), route, req.Method)
+	})
+}
@@ -0,0 +1,53 @@
+package server
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestMetrics(t *testing.T) {
+	s := New()
+
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.0, nil
+	}
+
+	for _, url := range []string{
+		"/mock?from=USD&to=EUR&date=2017-03-02",
+		"/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02",
+		"/v1/stores/mock/rate?from=USD&date=2017-03-02",
+		"/v1/nothing/here",
+	} {
+		req, err := http.NewRequest("GET", url, nil)
+		if err != nil {
+			t.Fatal(err)
+		}
+		s.ServeHTTP(httptest.NewRecorder(), req)
+	}
+
+	req, err := http.NewRequest("GET", "/metrics", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected code=%v, got %v", http.StatusOK, rr.Code)
+	}
+	for _, metric := range []string{
+		`exchangerates_http_requests_total{route="/{store}",method="GET",status="200"}`,
+		`exchangerates_http_requests_total{route="/v1/stores/{store}/rate",method="GET",status="200"}`,
+		`exchangerates_http_requests_total{route="/v1/stores/{store}/rate",method="GET",status="400"}`,
+		`exchangerates_http_requests_total{route="/v1",method="GET",status="404"}`,
+		`exchangerates_http_request_duration_seconds_count{route="/{store}",method="GET"}`,
+		`exchangerates_store_lookup_duration_seconds_count{store="mock",method="GetExchangeRate"}`,
+		`exchangerates_http_conditional_responses_total{result="full"}`,
+	} {
+		if !strings.Contains(rr.Body.String(), "\n"+metric+" ") {
+			t.Errorf("expected metrics to contain %s", metric)
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// schema is a JSON object of the OpenAPI document, usually a schema object
+type schema map[string]interface{}
+
+// apiParam describes a parameter of an API route
+type apiParam struct {
+	Name        string
+	In          string // query when empty
+	Type        string // string when empty
+	Format      string
+	Required    bool
+	Enum        []string
+	Description string
+}
+
+// apiRoute describes a route of the versioned API
+type apiRoute struct {
+	Method   string
+	Path     string
+	Summary  string
+	Params   []apiParam
+	Body     schema   // schema of the JSON request body, if any
+	Status   int      // status of a successful response, 200 when zero
+	Response schema   // schema of the data of a successful JSON response, if any
+	Produces []string // content types a successful response can have besides JSON
+	handler  http.HandlerFunc
+}
+
+// serve validates the query parameters of the request before handing it to the route's handler
+func (rt apiRoute) serve(w http.ResponseWriter, req *http.Request) {
+	err := rt.validate(req)
+	if err != nil {
+		writeError(w, invalidParameter(err))
+		return
+	}
+	rt.handler(w, req)
+}
+
+func (rt apiRoute) validate(req *http.Request) error {
+	query := req.URL.Query()
+	for _, p := range rt.Params {
+		if p.In != "" && p.In != "query" {
+			continue
+		}
+
+		value := query.Get(p.Name)
+		switch {
+		case value == "":
+			if p.Required {
+				return errors.New(`missing "` + p.Name + `" URL parameter`)
+			}
+		case p.Type == "integer":
+			if _, err := strconv.Atoi(value); err != nil {
+				return errors.New(`incorrect "` + p.Name + `" URL parameter`)
+			}
+		case p.Enum != nil && !contains(p.Enum, value):
+			return errors.New(`"` + p.Name + `" should be one of ` + strings.Join(p.Enum[:len(p.Enum)-1], ", ") + " or " + p.Enum[len(p.Enum)-1])
+		}
+	}
+	return nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// operation returns the OpenAPI operation object of the route
+func (rt apiRoute) operation() schema {
+	params := make([]schema, len(rt.Params))
+	for i, p := range rt.Params {
+		s := schema{"type": "string"}
+		if p.Type != "" {
+			s["type"] = p.Type
+		}
+		if p.Format != "" {
+			s["format"] = p.Format
+		}
+		if p.Enum != nil {
+			s["enum"] = p.Enum
+		}
+		params[i] = schema{"name": p.Name, "in": "query", "required": p.Required, "description": p.Description, "schema": s}
+		if p.In != "" {
+			params[i]["in"] = p.In
+		}
+	}
+
+	status := rt.Status
+	if status == 0 {
+		status = http.StatusOK
+	}
+	success := schema{"description": http.StatusText(status)}
+	content := schema{}
+	if rt.Response != nil {
+		content["application/json"] = schema{"schema": object([]string{"data"}, schema{"data": rt.Response})}
+	}
+	for _, contentType := range rt.Produces {
+		s := schema{"type": "string"}
+		if strings.HasPrefix(contentType, "image/") {
+			s["format"] = "binary"
+		}
+		content[contentType] = schema{"schema": s}
+	}
+	if len(content) > 0 {
+		success["content"] = content
+	}
+
+	op := schema{
+		"summary":    rt.Summary,
+		"parameters": params,
+		"responses": schema{
+			strconv.Itoa(status): success,
+			"default": schema{
+				"description": "Error",
+				"content":     schema{"application/json": schema{"schema": ref("ErrorResponse")}},
+			},
+		},
+	}
+	if rt.Body != nil {
+		op["requestBody"] = schema{"required": true, "content": schema{"application/json": schema{"schema": rt.Body}}}
+	}
+	return op
+}
+
+// openAPI returns the OpenAPI document describing the versioned API
+func (s *Server) openAPI() schema {
+	paths := schema{}
+	for _, rt := range s.v1Routes() {
+		path := "/v1" + rt.Path
+		item, ok := paths[path].(schema)
+		if !ok {
+			item = schema{}
+			paths[path] = item
+		}
+		item[strings.ToLower(rt.Method)] = rt.operation()
+	}
+
+	return schema{
+		"openapi": "3.0.3",
+		"info": schema{
+			"title":       "exchangerates",
+			"version":     "1.0.0",
+			"description": "Exchange rates from central banks and other sources. Successful JSON responses wrap their result in a data member, failures are described by an error member.",
+		},
+		"paths": paths,
+		"components": schema{
+			"schemas": apiSchemas,
+			"securitySchemes": schema{
+				"apiKey": schema{"type": "apiKey", "in": "header", "name": "X-API-Key"},
+				"bearer": schema{"type": "http", "scheme": "bearer"},
+			},
+		},
+		// keys are only required when the server is configured with them
+		"security": []schema{{}, {"apiKey": []string{}}, {"bearer": []string{}}},
+	}
+}
+
+func (s *Server) openAPIHandler(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	err := json.NewEncoder(w).Encode(s.openAPI())
+	if err != nil {
+		log.Println("writing response failed:", err)
+	}
+}
+
+func ref(name string) schema {
+	return schema{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(items schema) schema {
+	return schema{"type": "array", "items": items}
+}
+
+func object(required []string, properties schema) schema {
+	s := schema{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		s["required"] = required
+	}
+	return s
+}
+
+var (
+	stringSchema   = schema{"type": "string"}
+	numberSchema   = schema{"type": "number"}
+	dateSchema     = schema{"type": "string", "format": "date"}
+	dateTimeSchema = schema{"type": "string", "format": "date-time"}
+)
+
+// apiSchemas are the schemas of the request and response bodies of the versioned API
+var apiSchemas = schema{
+	"Error": object([]string{"code", "message"}, schema{
+		"code":    stringSchema,
+		"message": stringSchema,
+	}),
+	"ErrorResponse": object([]string{"error"}, schema{
+		"error": ref("Error"),
+	}),
+	"Rate": object([]string{"from", "to", "date", "store", "rate"}, schema{
+		"from":  stringSchema,
+		"to":    stringSchema,
+		"date":  dateSchema,
+		"store": stringSchema,
+		"rate":  numberSchema,
+		"path":  arrayOf(stringSchema),
+	}),
+	"DateRate": object([]string{"date", "rate"}, schema{
+		"date": dateSchema,
+		"rate": numberSchema,
+	}),
+	"OHLC": object([]string{"date", "open", "high", "low", "close"}, schema{
+		"date":  dateSchema,
+		"open":  numberSchema,
+		"high":  numberSchema,
+		"low":   numberSchema,
+		"close": numberSchema,
+	}),
+	"Matrix": object([]string{"store", "date", "currencies", "rates", "source"}, schema{
+		"store":      stringSchema,
+		"date":       dateSchema,
+		"currencies": arrayOf(stringSchema),
+		"rates":      arrayOf(arrayOf(numberSchema)),
+		"source":     schema{"type": "string", "enum": []string{"quotes", "rates"}},
+	}),
+	"BatchQuery": object([]string{"from", "to"}, schema{
+		"from": stringSchema,
+		"to":   stringSchema,
+		"date": stringSchema,
+	}),
+	"BatchItem": object([]string{"from", "to", "date"}, schema{
+		"from":  stringSchema,
+		"to":    stringSchema,
+		"date":  stringSchema,
+		"rate":  numberSchema,
+		"error": ref("Error"),
+	}),
+	"Drawdown": object([]string{"peak", "trough", "value"}, schema{
+		"peak":   ref("DateRate"),
+		"trough": ref("DateRate"),
+		"value":  numberSchema,
+	}),
+	"Analytics": object([]string{"min", "max", "maxDrawdown", "volatility", "annualisedVolatility", "returns", "rollingVolatility", "zScores"}, schema{
+		"min":                  ref("DateRate"),
+		"max":                  ref("DateRate"),
+		"maxDrawdown":          ref("Drawdown"),
+		"volatility":           numberSchema,
+		"annualisedVolatility": numberSchema,
+		"returns":              arrayOf(ref("DateRate")),
+		"rollingVolatility":    arrayOf(ref("DateRate")),
+		"zScores":              arrayOf(ref("DateRate")),
+		"correlation":          numberSchema,
+	}),
+	"AlertRuleInput": object([]string{"store", "from", "to", "condition", "webhook"}, schema{
+		"store":     stringSchema,
+		"from":      stringSchema,
+		"to":        stringSchema,
+		"condition": schema{"type": "string", "enum": []string{"above", "below", "change"}},
+		"threshold": numberSchema,
+		"webhook":   stringSchema,
+		"secret":    stringSchema,
+	}),
+	"AlertRule": object([]string{"id", "store", "from", "to", "condition", "threshold", "webhook", "created"}, schema{
+		"id":        stringSchema,
+		"store":     stringSchema,
+		"from":      stringSchema,
+		"to":        stringSchema,
+		"condition": schema{"type": "string", "enum": []string{"above", "below", "change"}},
+		"threshold": numberSchema,
+		"webhook":   stringSchema,
+		"lastFired": dateSchema,
+		"created":   dateTimeSchema,
+	}),
+	"Usage": object([]string{"name", "requests", "limited", "today"}, schema{
+		"name":     stringSchema,
+		"requests": schema{"type": "integer"},
+		"limited":  schema{"type": "integer"},
+		"today":    schema{"type": "integer"},
+		"quota":    schema{"type": "integer"},
+	}),
+	"AlertFiring": object([]string{"ruleId", "date", "rate", "previous", "fired", "delivered", "attempts"}, schema{
+		"ruleId":    stringSchema,
+		"date":      dateSchema,
+		"rate":      numberSchema,
+		"previous":  numberSchema,
+		"fired":     dateTimeSchema,
+		"delivered": schema{"type": "boolean"},
+		"attempts":  schema{"type": "integer"},
+		"error":     stringSchema,
+	}),
+}
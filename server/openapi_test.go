@@ -0,0 +1,285 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"mime"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/alert"
+	"github.com/farhan-shahid/exchangerates/auth"
+)
+
+// validator checks JSON values against the schemas of an OpenAPI document
+type validator struct {
+	schemas map[string]interface{}
+}
+
+func (v validator) validate(s map[string]interface{}, value interface{}, at string) error {
+	if r, ok := s["$ref"].(string); ok {
+		target, ok := v.schemas[strings.TrimPrefix(r, "#/components/schemas/")].(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: unknown schema %s", at, r)
+		}
+		return v.validate(target, value, at)
+	}
+
+	if oneOf, ok := s["oneOf"].([]interface{}); ok {
+		matches := 0
+		for _, o := range oneOf {
+			if v.validate(o.(map[string]interface{}), value, at) == nil {
+				matches++
+			}
+		}
+		if matches != 1 {
+			return fmt.Errorf("%s: matches %d of the oneOf schemas", at, matches)
+		}
+		return nil
+	}
+
+	if enum, ok := s["enum"].([]interface{}); ok {
+		found := false
+		for _, e := range enum {
+			found = found || e == value
+		}
+		if !found {
+			return fmt.Errorf("%s: %v is not one of %v", at, value, enum)
+		}
+	}
+
+	switch s["type"] {
+	case "object":
+		m, ok := value.(map[string]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an object, got %T", at, value)
+		}
+		required, _ := s["required"].([]interface{})
+		for _, name := range required {
+			if _, ok := m[name.(string)]; !ok {
+				return fmt.Errorf("%s: missing required property %s", at, name)
+			}
+		}
+		properties, _ := s["properties"].(map[string]interface{})
+		for name, val := range m {
+			ps, ok := properties[name].(map[string]interface{})
+			if !ok {
+				return fmt.Errorf("%s: undocumented property %s", at, name)
+			}
+			if err := v.validate(ps, val, at+"."+name); err != nil {
+				return err
+			}
+		}
+	case "array":
+		a, ok := value.([]interface{})
+		if !ok {
+			return fmt.Errorf("%s: expected an array, got %T", at, value)
+		}
+		for i, item := range a {
+			if err := v.validate(s["items"].(map[string]interface{}), item, at+"["+strconv.Itoa(i)+"]"); err != nil {
+				return err
+			}
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			return fmt.Errorf("%s: expected a string, got %T", at, value)
+		}
+		layout := map[interface{}]string{"date": "2006-01-02", "date-time": time.RFC3339}[s["format"]]
+		if _, err := time.Parse(layout, str); layout != "" && err != nil {
+			return fmt.Errorf("%s: %q is not a %s", at, str, s["format"])
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			return fmt.Errorf("%s: expected a number, got %T", at, value)
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			return fmt.Errorf("%s: expected an integer, got %v", at, value)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fmt.Errorf("%s: expected a boolean, got %T", at, value)
+		}
+	}
+	return nil
+}
+
+func TestOpenAPI(t *testing.T) {
+	// samples are requests to every operation of the document, {id} is replaced by the ID of the
+	// alert rule created by the POST /v1/alerts sample
+	var samples = []struct {
+		op   string
+		url  string
+		body string
+	}{
+		{op: "GET /v1/stores", url: "/v1/stores"},
+		{op: "GET /v1/stores/{store}/rate", url: "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02"},
+		{op: "GET /v1/stores/{store}/rate", url: "/v1/stores/mock/rate?from=USD&to=XYZ&date=2017-03-02"},
+		{op: "GET /v1/stores/{store}/rate", url: "/v1/stores/mock/rate?from=USD&to=ERR&date=2017-03-02"},
+		{op: "GET /v1/stores/{store}/rate", url: "/v1/stores/mock/rate?to=EUR"},
+		{op: "GET /v1/stores/{store}/series", url: "/v1/stores/mock/series?from=USD&to=EUR&start=2017-03-01&end=2017-03-20&period=weekly"},
+		{op: "GET /v1/stores/{store}/series", url: "/v1/stores/mock/series?from=USD&to=EUR&start=2017-03-01&end=2017-03-20&method=ohlc"},
+		{op: "GET /v1/stores/{store}/series", url: "/v1/stores/mock/series?from=USD&to=EUR&start=2017-03-01&end=2017-03-20&format=csv"},
+		{op: "GET /v1/stores/{store}/series", url: "/v1/stores/mock/series?from=USD&to=EUR&start=2017-03-01&period=hourly"},
+		{op: "GET /v1/matrix", url: "/v1/matrix?store=mock&currencies=USD,EUR,GBP&date=2017-03-02"},
+		{op: "GET /v1/matrix", url: "/v1/matrix?store=mock&currencies=USD,EUR&format=csv"},
+		{op: "POST /v1/batch", url: "/v1/batch?store=mock", body: `[{"from":"USD","to":"EUR","date":"2017-03-02"},{"from":"USD","to":"XYZ"}]`},
+		{op: "POST /v1/batch", url: "/v1/batch?store=mock&concurrency=many", body: `[]`},
+		{op: "GET /v1/analytics", url: "/v1/analytics?store=mock&from=USD&to=EUR&start=2017-03-01&end=2017-03-20&window=5&compare=EUR/GBP"},
+		{op: "GET /v1/analytics", url: "/v1/analytics?store=mock&from=USD&to=EUR&start=2017-03-01&end=2017-03-20&returns=cubic"},
+		{op: "GET /v1/chart", url: "/v1/chart?from=USD&to=EUR&month=3&year=2017"},
+		{op: "GET /v1/chart", url: "/v1/chart?from=USD&to=EUR&month=March&year=2017"},
+		{op: "GET /v1/chart", url: "/v1/chart?from=USD&to=EUR&month=3&year=2017&format=svg&theme=dark&grid=true&width=600&height=300"},
+		{op: "GET /v1/chart", url: "/v1/chart?from=USD&to=EUR&month=3&year=2017&width=10000"},
+		{op: "GET /v1/chart/compare", url: "/v1/chart/compare?store=mock&pairs=EURUSD,EUR/GBP&start=2017-03-01&end=2017-03-20&rebase=true"},
+		{op: "GET /v1/chart/compare", url: "/v1/chart/compare?store=mock&pairs=EURUSD,EURJPY&secondary=EURJPY&start=2017-03&format=svg"},
+		{op: "GET /v1/chart/compare", url: "/v1/chart/compare?store=mock&pairs=EURUSD&start=2017-03-01&format=gif"},
+		{op: "POST /v1/alerts", url: "/v1/alerts", body: `{"store":"mock","from":"USD","to":"EUR","condition":"above","threshold":1.2,"webhook":"http://example.com/hook","secret":"s3cret"}`},
+		{op: "POST /v1/alerts", url: "/v1/alerts", body: `{"store":"mock","from":"USD","to":"EUR","condition":"sideways","webhook":"http://example.com/hook"}`},
+		{op: "GET /v1/alerts", url: "/v1/alerts"},
+		{op: "GET /v1/alerts/{id}", url: "/v1/alerts/{id}"},
+		{op: "GET /v1/alerts/{id}", url: "/v1/alerts/missing"},
+		{op: "PUT /v1/alerts/{id}", url: "/v1/alerts/{id}", body: `{"store":"mock","from":"USD","to":"EUR","condition":"below","threshold":1.1,"webhook":"http://example.com/hook"}`},
+		{op: "GET /v1/alerts/{id}/history", url: "/v1/alerts/{id}/history"},
+		{op: "DELETE /v1/alerts/{id}", url: "/v1/alerts/{id}"},
+		{op: "DELETE /v1/alerts/{id}", url: "/v1/alerts/{id}"},
+		{op: "GET /v1/usage", url: "/v1/usage"},
+	}
+
+	s := New()
+	m, err := alert.New("", LookupStore)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetAlerts(m)
+	keys, err := auth.New(keySource{{Key: "k1", Name: "reporting"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetAuth(keys)
+
+	moc.OnGetQuotes = nil
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		switch to {
+		case "XYZ":
+			return 0, exchangerates.NotFound("currency XYZ not found")
+		case "ERR":
+			return 0, errors.New("connection refused")
+		}
+		return 1.0, nil
+	}
+	moc.OnGetMonthExchangeRates = func(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+		var rates []exchangerates.DateRate
+		for day := 1; day <= 28; day++ {
+			date := time.Date(year, time.Month(month), day, 0, 0, 0, 0, time.UTC)
+			rates = append(rates, exchangerates.DateRate{Date: date, Rate: 1 + float64(day%5)/100})
+		}
+		return rates, nil
+	}
+
+	req, err := http.NewRequest("GET", "/openapi.json", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rr := httptest.NewRecorder()
+	s.ServeHTTP(rr, req)
+	if rr.Code != http.StatusOK {
+		t.Fatalf("expected code=%v, got %v", http.StatusOK, rr.Code)
+	}
+
+	var doc struct {
+		OpenAPI    string
+		Paths      map[string]map[string]map[string]interface{}
+		Components struct {
+			Schemas map[string]interface{}
+		}
+	}
+	err = json.NewDecoder(rr.Body).Decode(&doc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if doc.OpenAPI != "3.0.3" {
+		t.Errorf("expected openapi=3.0.3, got %v", doc.OpenAPI)
+	}
+	v := validator{schemas: doc.Components.Schemas}
+
+	covered := map[string]bool{}
+	id := ""
+	for i, sample := range samples {
+		parts := strings.SplitN(sample.op, " ", 2)
+		op, ok := doc.Paths[parts[1]][strings.ToLower(parts[0])]
+		if !ok {
+			t.Errorf("#%d failed: %s is not documented", i, sample.op)
+			continue
+		}
+		covered[sample.op] = true
+
+		req, err := http.NewRequest(parts[0], strings.Replace(sample.url, "{id}", id, -1), strings.NewReader(sample.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		req.Header.Set("X-API-Key", "k1")
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		responses := op["responses"].(map[string]interface{})
+		key := strconv.Itoa(rr.Code)
+		if rr.Code >= 400 {
+			key = "default"
+		}
+		resp, ok := responses[key].(map[string]interface{})
+		if !ok {
+			t.Errorf("#%d failed: status %v of %s is not documented: %s", i, rr.Code, sample.op, rr.Body.String())
+			continue
+		}
+
+		content, _ := resp["content"].(map[string]interface{})
+		if rr.Body.Len() == 0 {
+			if content != nil {
+				t.Errorf("#%d failed: expected a response body", i)
+			}
+			continue
+		}
+		mediaType, _, _ := mime.ParseMediaType(rr.Header().Get("Content-Type"))
+		media, ok := content[mediaType].(map[string]interface{})
+		if !ok {
+			t.Errorf("#%d failed: content type %q of %s is not documented", i, mediaType, sample.op)
+			continue
+		}
+		if mediaType != "application/json" {
+			continue
+		}
+
+		var body interface{}
+		err = json.Unmarshal(rr.Body.Bytes(), &body)
+		if err != nil {
+			t.Errorf("#%d failed: %v", i, err)
+			continue
+		}
+		err = v.validate(media["schema"].(map[string]interface{}), body, "response")
+		if err != nil {
+			t.Errorf("#%d failed: %s %s: %v", i, sample.op, rr.Body.String(), err)
+		}
+
+		if sample.op == "POST /v1/alerts" && rr.Code == http.StatusCreated {
+			id = body.(map[string]interface{})["data"].(map[string]interface{})["id"].(string)
+		}
+	}
+
+	for path, item := range doc.Paths {
+		for method := range item {
+			op := strings.ToUpper(method) + " " + path
+			if !covered[op] {
+				t.Errorf("%s has no sample request", op)
+			}
+		}
+	}
+}
@@ -1,59 +1,104 @@
 package server
 
 import (
-	"io"
 	"net/http"
+	"sort"
+	"sync"
+	"time"
 
 	"github.com/farhan-shahid/exchangerates"
-	"github.com/farhan-shahid/exchangerates/ecb"
-	"github.com/farhan-shahid/exchangerates/ecbsql"
-	"github.com/farhan-shahid/exchangerates/googlefinance"
+	"github.com/farhan-shahid/exchangerates/alert"
+	"github.com/farhan-shahid/exchangerates/auth"
+	"github.com/farhan-shahid/exchangerates/metrics"
 	"github.com/farhan-shahid/exchangerates/mock"
 	"github.com/gorilla/mux"
 )
 
 type rateResp struct {
 	Rate float64
+	Path []string `json:",omitempty"`
 }
 
-var (
-	ec    exchangerates.Store = ecb.New()
-	ecsql exchangerates.Store = ecbsql.New()
-	goog  exchangerates.Store = googlefinance.New()
-	moc                       = mock.New()
-)
+// moc is served until SetStores is called, so that the server can be exercised without network access
+var moc = mock.New()
+
+// stores maps store names used in routes to their instances, instrumented to record lookup metrics
+var stores = map[string]exchangerates.Store{
+	"mock": metrics.Instrument(metrics.Default, "mock", moc),
+}
+
+// defaultStore answers the requests that do not name a store
+var defaultStore = "ecb"
 
-type loggingHandler struct {
-	w io.Writer
-	h http.Handler
+// SetStores replaces the stores served under the names used in routes, requests not naming a store
+// being answered by defaultName. It should be called before the server handles requests
+func SetStores(m map[string]exchangerates.Store, defaultName string) {
+	instrumented := make(map[string]exchangerates.Store, len(m))
+	for name, s := range m {
+		instrumented[name] = metrics.Instrument(metrics.Default, name, s)
+	}
+	stores, defaultStore = instrumented, defaultName
 }
 
-func (l *loggingHandler) ServeHTTP(rw http.ResponseWriter, r *http.Request) {
-	l.w.Write([]byte(r.URL.String()))
-	l.h.ServeHTTP(rw, r)
+// LookupStore returns the store served under the given name
+func LookupStore(name string) (exchangerates.Store, bool) {
+	s, ok := stores[name]
+	return s, ok
 }
 
-// LoggingHandler returns a http.Handler that logs requests before calling given handler's ServeHTTP method
-func LoggingHandler(w io.Writer, h http.Handler) http.Handler {
-	return &loggingHandler{w: w, h: h}
+// StoreNames returns the names of all stores that are served
+func StoreNames() []string {
+	names := make([]string, 0, len(stores))
+	for name := range stores {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
 
 // Server type manages routes for accessing exchange rates over http
 type Server struct {
-	h http.Handler
+	h         http.Handler
+	accessLog *accessLogger
+	alerts    *alert.Manager
+	keys      *auth.Manager
+	router    *mux.Router
+	hub       *hub
+	heartbeat time.Duration
+
+	mu            sync.RWMutex // guards the readiness settings, which may change while serving
+	readyStores   []string
+	maxAge        time.Duration
+	streamOrigins []string
+	metricsAuth   bool
 }
 
 // New returns a *Server with the necessary routing handler(s) attached
 func New() *Server {
+	s := &Server{hub: newHub(), heartbeat: streamHeartbeat}
 	r := mux.NewRouter()
-	r.HandleFunc("/chart", getChartHandler)
-	r.HandleFunc("/{store}", getRateHandler)
-	return &Server{h: r}
-}
-
-// AddLogging adds request logging using LoggingHandler
-func (s *Server) AddLogging(w io.Writer) {
-	s.h = LoggingHandler(w, s.h)
+	r.NotFoundHandler = http.HandlerFunc(notFoundHandler)
+	s.addV1Routes(r)
+	r.HandleFunc("/openapi.json", s.openAPIHandler).Methods("GET")
+	r.Handle("/metrics", metrics.Default).Methods("GET")
+	r.HandleFunc("/healthz", healthzHandler).Methods("GET")
+	r.HandleFunc("/readyz", s.readyzHandler).Methods("GET")
+	r.HandleFunc("/status", statusHandler).Methods("GET")
+	r.HandleFunc("/alerts", s.alertsHandler).Methods("GET", "POST")
+	r.HandleFunc("/alerts/{id}", s.alertsHandler).Methods("GET", "PUT", "DELETE")
+	r.HandleFunc("/alerts/{id}/history", s.alertHistoryHandler).Methods("GET")
+	r.HandleFunc("/stream", s.sseHandler)
+	r.HandleFunc("/stream/ws", s.wsHandler)
+	r.HandleFunc("/chart", cached(chartFixing, getChartHandler))
+	r.HandleFunc("/chart/compare", cached(compareFixing, getCompareChartHandler))
+	r.HandleFunc("/matrix", cached(matrixFixing, getMatrixHandler))
+	r.HandleFunc("/analytics", cached(analyticsFixing, getAnalyticsHandler))
+	r.HandleFunc("/batch", batchHandler).Methods("POST")
+	r.HandleFunc("/{store}/series", cached(seriesFixing, getSeriesHandler))
+	r.HandleFunc("/{store}", cached(rateFixing, getRateHandler))
+	s.router = r
+	s.h = instrument(r, s.authenticate(r))
+	return s
 }
 
 func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
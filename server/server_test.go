@@ -0,0 +1,21 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/mock"
+)
+
+func TestStoresWithoutNetwork(t *testing.T) {
+	// stores reaching the network are built by the caller and handed to SetStores, so that neither
+	// importing the server nor testing it downloads any dataset
+	for name, s := range stores {
+		if _, ok := exchangerates.Unwrap(s).(*mock.Store); !ok {
+			t.Errorf("%s: expected only the mock store to be served before SetStores, got %T", name, exchangerates.Unwrap(s))
+		}
+	}
+	if names := StoreNames(); len(names) != 1 || names[0] != "mock" {
+		t.Errorf("expected the mock store only, got %v", names)
+	}
+}
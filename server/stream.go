@@ -0,0 +1,338 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"golang.org/x/net/websocket"
+)
+
+const (
+	streamBuffer    = 16 // updates queued per connection before the oldest ones are dropped
+	streamLookback  = 10 // days searched for the latest rate of a pair
+	streamHeartbeat = 15 * time.Second
+	streamWriteWait = 10 * time.Second // time allowed for a message to be written to a client
+)
+
+type pair struct {
+	From string
+	To   string
+}
+
+// streamMsg is sent to streaming clients, either a rate update or a heartbeat
+type streamMsg struct {
+	Type  string
+	Store string  `json:",omitempty"`
+	From  string  `json:",omitempty"`
+	To    string  `json:",omitempty"`
+	Date  string  `json:",omitempty"`
+	Rate  float64 `json:",omitempty"`
+}
+
+// subscribeMsg is received from WebSocket clients to change the pairs they are subscribed to
+type subscribeMsg struct {
+	Subscribe   []string
+	Unsubscribe []string
+}
+
+type subscriber struct {
+	store string
+	ch    chan streamMsg
+
+	mu    sync.Mutex
+	pairs map[pair]streamMsg // last update sent for every subscribed pair
+}
+
+// hub fans out rate updates to streaming clients
+type hub struct {
+	mu   sync.Mutex
+	subs map[*subscriber]struct{}
+
+	closeOnce sync.Once
+	done      chan struct{} // closed to end every stream
+}
+
+func newHub() *hub {
+	return &hub{subs: make(map[*subscriber]struct{}), done: make(chan struct{})}
+}
+
+func (h *hub) subscribe(store string, pairs []pair) *subscriber {
+	sub := &subscriber{store: store, ch: make(chan streamMsg, streamBuffer), pairs: make(map[pair]streamMsg)}
+	for _, p := range pairs {
+		sub.pairs[p] = streamMsg{}
+	}
+
+	h.mu.Lock()
+	h.subs[sub] = struct{}{}
+	h.mu.Unlock()
+
+	sub.update()
+	return sub
+}
+
+func (h *hub) unsubscribe(sub *subscriber) {
+	h.mu.Lock()
+	delete(h.subs, sub)
+	h.mu.Unlock()
+}
+
+// publish sends the subscribers to the stores matched the rates of their pairs that changed since they
+// were last sent
+func (h *hub) publish(match func(store string) bool) {
+	h.mu.Lock()
+	subs := make([]*subscriber, 0, len(h.subs))
+	for sub := range h.subs {
+		if match(sub.store) {
+			subs = append(subs, sub)
+		}
+	}
+	h.mu.Unlock()
+
+	for _, sub := range subs {
+		sub.update()
+	}
+}
+
+func (sub *subscriber) setPairs(add, remove []pair) {
+	sub.mu.Lock()
+	for _, p := range add {
+		if _, ok := sub.pairs[p]; !ok {
+			sub.pairs[p] = streamMsg{}
+		}
+	}
+	for _, p := range remove {
+		delete(sub.pairs, p)
+	}
+	sub.mu.Unlock()
+
+	sub.update()
+}
+
+func (sub *subscriber) update() {
+	store, ok := stores[sub.store]
+	if !ok {
+		return
+	}
+
+	sub.mu.Lock()
+	defer sub.mu.Unlock()
+	for p, last := range sub.pairs {
+		date, rate, err := exchangerates.GetLatestExchangeRate(store, p.From, p.To, time.Now(), streamLookback)
+		if err != nil || (date == last.Date && rate == last.Rate) {
+			continue
+		}
+		msg := streamMsg{Type: "rate", Store: sub.store, From: p.From, To: p.To, Date: date, Rate: rate}
+		sub.pairs[p] = msg
+		sub.send(msg)
+	}
+}
+
+// send queues a message without blocking, dropping the oldest queued message when the client is too slow
+func (sub *subscriber) send(msg streamMsg) {
+	for {
+		select {
+		case sub.ch <- msg:
+			return
+		default:
+		}
+		select {
+		case <-sub.ch:
+		default:
+		}
+	}
+}
+
+// CloseStreams ends the streams of every client, so they do not hold up a graceful shutdown.
+// Streams opened afterwards end immediately
+func (s *Server) CloseStreams() {
+	s.hub.closeOnce.Do(func() {
+		close(s.hub.done)
+	})
+}
+
+// Publish sends streaming clients the rates that changed, it should be called after stores are refreshed
+func (s *Server) Publish() {
+	s.hub.publish(func(string) bool { return true })
+}
+
+// PublishStore is like Publish but only for the clients streaming the rates of the named store, such as
+// one that has just been refreshed
+func (s *Server) PublishStore(name string) {
+	s.hub.publish(func(store string) bool { return store == name })
+}
+
+// SetStreamOrigins sets the origins of the web pages allowed to open WebSocket streams besides those
+// served by the server itself, such as https://example.com, or * for any
+func (s *Server) SetStreamOrigins(origins []string) {
+	s.streamOrigins = origins
+}
+
+// checkOrigin refuses the WebSocket handshakes of web pages from other sites, which would otherwise
+// stream with the credentials of the browser's user. Clients other than browsers send no origin
+func (s *Server) checkOrigin(config *websocket.Config, req *http.Request) error {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return nil
+	}
+	if u, err := url.Parse(origin); err == nil && strings.EqualFold(u.Host, req.Host) {
+		return nil
+	}
+	for _, o := range s.streamOrigins {
+		if o == "*" || strings.EqualFold(strings.TrimSuffix(o, "/"), origin) {
+			return nil
+		}
+	}
+	return errors.New("origin " + origin + " is not allowed")
+}
+
+// parsePairs returns the comma separated currency pairs of value, written either EUR/USD or EURUSD
+func parsePairs(value string) ([]pair, error) {
+	var pairs []pair
+	for _, p := range strings.Split(value, ",") {
+		if p == "" {
+			continue
+		}
+		currs := strings.Split(p, "/")
+		if len(currs) == 1 && len(p) == 6 {
+			currs = []string{p[:3], p[3:]}
+		}
+		if len(currs) != 2 || currs[0] == "" || currs[1] == "" {
+			return nil, errors.New("incorrect pair " + p + ", should be similar to EUR/USD")
+		}
+		pairs = append(pairs, pair{From: currs[0], To: currs[1]})
+	}
+	return pairs, nil
+}
+
+func getStreamFormValues(req *http.Request) (store string, pairs []pair, err error) {
+	store = req.FormValue("store")
+	if store == "" {
+		store = defaultStore
+	}
+	if _, ok := stores[store]; !ok {
+		err = errors.New(store + " is not a valid store")
+		return
+	}
+
+	if req.FormValue("pairs") == "" {
+		err = errors.New(`missing "pairs" URL parameter`)
+		return
+	}
+	pairs, err = parsePairs(req.FormValue("pairs"))
+	return
+}
+
+// sseHandler streams rate updates as Server-Sent Events
+func (s *Server) sseHandler(w http.ResponseWriter, req *http.Request) {
+	store, pairs, err := getStreamFormValues(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		http.Error(w, "streaming is not supported", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.WriteHeader(http.StatusOK)
+	flusher.Flush()
+
+	sub := s.hub.subscribe(store, pairs)
+	defer s.hub.unsubscribe(sub)
+
+	// the stream outlives the server's write timeout, so every write gets its own deadline
+	rc := http.NewResponseController(w)
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+	for {
+		rc.SetWriteDeadline(time.Time{})
+		select {
+		case msg := <-sub.ch:
+			data, _ := json.Marshal(&msg)
+			rc.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			_, err = w.Write([]byte("event: rate\ndata: " + string(data) + "\n\n"))
+		case <-heartbeat.C:
+			rc.SetWriteDeadline(time.Now().Add(streamWriteWait))
+			_, err = w.Write([]byte(": heartbeat\n\n"))
+		case <-req.Context().Done():
+			return
+		case <-s.hub.done:
+			return
+		}
+		if err != nil {
+			return
+		}
+		flusher.Flush()
+	}
+}
+
+// wsHandler streams rate updates over a WebSocket. Clients may change their subscriptions
+// by sending messages such as {"Subscribe": ["EUR/GBP"], "Unsubscribe": ["EUR/USD"]}
+func (s *Server) wsHandler(w http.ResponseWriter, req *http.Request) {
+	store, pairs, err := getStreamFormValues(req)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	websocket.Server{Handshake: s.checkOrigin, Handler: func(ws *websocket.Conn) {
+		defer ws.Close()
+
+		// the connection outlives the server's timeouts, clear the deadlines they set
+		ws.SetDeadline(time.Time{})
+
+		sub := s.hub.subscribe(store, pairs)
+		defer s.hub.unsubscribe(sub)
+
+		closed := make(chan struct{})
+		go func() {
+			defer close(closed)
+			for {
+				var msg subscribeMsg
+				if websocket.JSON.Receive(ws, &msg) != nil {
+					return
+				}
+				add, err := parsePairs(strings.Join(msg.Subscribe, ","))
+				if err != nil {
+					continue
+				}
+				remove, err := parsePairs(strings.Join(msg.Unsubscribe, ","))
+				if err != nil {
+					continue
+				}
+				sub.setPairs(add, remove)
+			}
+		}()
+
+		heartbeat := time.NewTicker(s.heartbeat)
+		defer heartbeat.Stop()
+		for {
+			var err error
+			select {
+			case msg := <-sub.ch:
+				ws.SetWriteDeadline(time.Now().Add(streamWriteWait))
+				err = websocket.JSON.Send(ws, &msg)
+			case <-heartbeat.C:
+				ws.SetWriteDeadline(time.Now().Add(streamWriteWait))
+				err = websocket.JSON.Send(ws, &streamMsg{Type: "heartbeat"})
+			case <-closed:
+				return
+			case <-s.hub.done:
+				return
+			}
+			if err != nil {
+				return
+			}
+		}
+	}}.ServeHTTP(w, req)
+}
@@ -0,0 +1,198 @@
+package server
+
+import (
+	"bufio"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/net/websocket"
+)
+
+func TestStream(t *testing.T) {
+	var (
+		mu   sync.Mutex
+		rate = 1.05
+	)
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		if from == "GBP" {
+			return 1.25, nil
+		}
+		return rate, nil
+	}
+
+	s := New()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/stream?store=mock&pairs=EUR/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if want, got := "text/event-stream", resp.Header.Get("Content-Type"); want != got {
+		t.Fatalf("expected content type=%q, got %q", want, got)
+	}
+	events := bufio.NewReader(resp.Body)
+
+	ws, err := websocket.Dial(strings.Replace(ts.URL, "http", "ws", 1)+"/stream/ws?store=mock&pairs=EUR/USD", "", ts.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ws.Close()
+
+	if want, got := 1.05, readEvent(t, events).Rate; want != got {
+		t.Fatalf("expected initial SSE rate=%v, got %v", want, got)
+	}
+	var msg streamMsg
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.05, msg.Rate; want != got {
+		t.Fatalf("expected initial WebSocket rate=%v, got %v", want, got)
+	}
+
+	mu.Lock()
+	rate = 1.07
+	mu.Unlock()
+	s.Publish()
+
+	if want, got := 1.07, readEvent(t, events).Rate; want != got {
+		t.Fatalf("expected updated SSE rate=%v, got %v", want, got)
+	}
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if want, got := 1.07, msg.Rate; want != got {
+		t.Fatalf("expected updated WebSocket rate=%v, got %v", want, got)
+	}
+
+	err = websocket.JSON.Send(ws, &subscribeMsg{Subscribe: []string{"GBP/USD"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := websocket.JSON.Receive(ws, &msg); err != nil {
+		t.Fatal(err)
+	}
+	if msg.From != "GBP" || msg.Rate != 1.25 {
+		t.Fatalf("expected GBP/USD update after subscribing, got %+v", msg)
+	}
+}
+
+func TestStreamOrigin(t *testing.T) {
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.05, nil
+	}
+
+	s := New()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+	url := strings.Replace(ts.URL, "http", "ws", 1) + "/stream/ws?store=mock&pairs=EUR/USD"
+
+	if _, err := websocket.Dial(url, "", "http://evil.example"); err == nil {
+		t.Fatal("expected a page of another site to be refused a stream")
+	}
+	s.SetStreamOrigins([]string{"http://evil.example/"})
+	ws, err := websocket.Dial(url, "", "http://evil.example")
+	if err != nil {
+		t.Fatalf("expected an allowed origin to open a stream, got %v", err)
+	}
+	ws.Close()
+}
+
+func TestPublishStore(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		lookups int
+	)
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		mu.Lock()
+		defer mu.Unlock()
+		lookups++
+		return 1.05, nil
+	}
+
+	s := New()
+	sub := s.hub.subscribe("mock", []pair{{From: "EUR", To: "USD"}})
+	defer s.hub.unsubscribe(sub)
+
+	count := func() int {
+		mu.Lock()
+		defer mu.Unlock()
+		return lookups
+	}
+	before := count()
+	s.PublishStore("ecb")
+	if n := count() - before; n != 0 {
+		t.Fatalf("expected the subscribers of other stores not to be updated, got %d lookups", n)
+	}
+	s.PublishStore("mock")
+	if count() == before {
+		t.Fatal("expected the subscribers of the store to be updated")
+	}
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) streamMsg {
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasPrefix(line, "data: ") {
+			var msg streamMsg
+			err = json.Unmarshal([]byte(strings.TrimPrefix(line, "data: ")), &msg)
+			if err != nil {
+				t.Fatal(err)
+			}
+			return msg
+		}
+	}
+}
+
+func TestSend(t *testing.T) {
+	sub := &subscriber{ch: make(chan streamMsg, 2)}
+	for i := 1; i <= 3; i++ {
+		sub.send(streamMsg{Rate: float64(i)})
+	}
+	if first := <-sub.ch; first.Rate != 2 {
+		t.Fatalf("expected oldest update to be dropped, got %+v", first)
+	}
+}
+
+func TestCloseStreams(t *testing.T) {
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		return 1.05, nil
+	}
+
+	s := New()
+	ts := httptest.NewServer(s)
+	defer ts.Close()
+
+	resp, err := http.Get(ts.URL + "/stream?store=mock&pairs=EUR/USD")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+
+	s.CloseStreams()
+	done := make(chan error)
+	go func() {
+		_, err := ioutil.ReadAll(resp.Body)
+		done <- err
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("expected the stream to end cleanly, got %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream was not closed")
+	}
+}
@@ -0,0 +1,89 @@
+package server
+
+import (
+	"crypto/tls"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// certCheckInterval is how often the certificate files are checked for changes
+const certCheckInterval = 10 * time.Second
+
+// CertReloader provides the TLS certificate loaded from a certificate and key file,
+// reloading it when the files change so renewed certificates are picked up without a restart
+type CertReloader struct {
+	certFile string
+	keyFile  string
+
+	mu      sync.Mutex
+	cert    *tls.Certificate
+	modTime time.Time // newest modification time of the files when they were loaded
+	checked time.Time
+	now     func() time.Time
+}
+
+// NewCertReloader returns a CertReloader for the given PEM encoded certificate and key files
+func NewCertReloader(certFile, keyFile string) (*CertReloader, error) {
+	c := &CertReloader{certFile: certFile, keyFile: keyFile, now: time.Now}
+	err := c.Reload()
+	if err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// modified returns the newest modification time of the certificate and key files
+func (c *CertReloader) modified() (time.Time, error) {
+	var newest time.Time
+	for _, name := range []string{c.certFile, c.keyFile} {
+		info, err := os.Stat(name)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(newest) {
+			newest = info.ModTime()
+		}
+	}
+	return newest, nil
+}
+
+// Reload loads the certificate from the files, keeping the current one if that fails
+func (c *CertReloader) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.load()
+}
+
+// load reads the files. The caller must hold c.mu
+func (c *CertReloader) load() error {
+	modTime, err := c.modified()
+	if err != nil {
+		return err
+	}
+	cert, err := tls.LoadX509KeyPair(c.certFile, c.keyFile)
+	if err != nil {
+		return err
+	}
+	c.cert = &cert
+	c.modTime = modTime
+	c.checked = c.now()
+	return nil
+}
+
+// GetCertificate returns the current certificate, for use as tls.Config.GetCertificate
+func (c *CertReloader) GetCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if now := c.now(); now.Sub(c.checked) >= certCheckInterval {
+		c.checked = now
+		if modTime, err := c.modified(); err == nil && !modTime.Equal(c.modTime) {
+			if err := c.load(); err != nil {
+				log.Printf("reloading TLS certificate failed: %v", err)
+			}
+		}
+	}
+	return c.cert, nil
+}
@@ -0,0 +1,103 @@
+package server
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// writeCert writes a self-signed certificate for name and its key to the files given
+func writeCert(t *testing.T, name, certFile, keyFile string, modTime time.Time) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	err = ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	os.Chtimes(certFile, modTime, modTime)
+	os.Chtimes(keyFile, modTime, modTime)
+}
+
+func commonName(t *testing.T, cert *tls.Certificate) string {
+	parsed, err := x509.ParseCertificate(cert.Certificate[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return parsed.Subject.CommonName
+}
+
+func TestCertReloader(t *testing.T) {
+	dir, err := ioutil.TempDir("", "tls")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	certFile, keyFile := filepath.Join(dir, "cert.pem"), filepath.Join(dir, "key.pem")
+
+	start := time.Now().Add(-time.Hour)
+	writeCert(t, "first", certFile, keyFile, start)
+	c, err := NewCertReloader(certFile, keyFile)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	c.now = func() time.Time { return now }
+
+	cert, _ := c.GetCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Fatalf("expected certificate first, got %v", name)
+	}
+
+	writeCert(t, "second", certFile, keyFile, start.Add(time.Minute))
+	cert, _ = c.GetCertificate(nil)
+	if name := commonName(t, cert); name != "first" {
+		t.Errorf("expected the files not to be checked again before %v, got %v", certCheckInterval, name)
+	}
+
+	now = now.Add(certCheckInterval)
+	cert, _ = c.GetCertificate(nil)
+	if name := commonName(t, cert); name != "second" {
+		t.Errorf("expected the changed certificate to be loaded, got %v", name)
+	}
+
+	err = ioutil.WriteFile(keyFile, []byte("garbage"), 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now = now.Add(certCheckInterval)
+	cert, _ = c.GetCertificate(nil)
+	if name := commonName(t, cert); name != "second" {
+		t.Errorf("expected the current certificate to be kept when reloading fails, got %v", name)
+	}
+}
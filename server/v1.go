@@ -0,0 +1,554 @@
+package server
+
+import (
+	"encoding/json"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+	"github.com/farhan-shahid/exchangerates/alert"
+	"github.com/farhan-shahid/exchangerates/analytics"
+	"github.com/farhan-shahid/exchangerates/series"
+	"github.com/gorilla/mux"
+)
+
+// apiError is an error reported to API clients with an HTTP status and a machine readable code
+type apiError struct {
+	Status  int    `json:"-"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *apiError) Error() string {
+	return e.Message
+}
+
+func invalidParameter(err error) error {
+	return &apiError{Status: http.StatusBadRequest, Code: "invalid_parameter", Message: err.Error()}
+}
+
+// lookupFailed reports the error of a store: not found when it holds no such rate, and otherwise as the
+// failure of the source of its data, such as a network or database error
+func lookupFailed(err error) error {
+	if exchangerates.IsNotFound(err) {
+		return &apiError{Status: http.StatusNotFound, Code: "rate_not_found", Message: err.Error()}
+	}
+	return &apiError{Status: http.StatusBadGateway, Code: "upstream_error", Message: err.Error()}
+}
+
+// legacyError writes err as plain text like the routes predating the versioned API, with the status of
+// lookupFailed when the store failed to answer, 404 for unknown stores and 400 otherwise
+func legacyError(w http.ResponseWriter, err error) {
+	code := http.StatusBadRequest
+	if e, ok := err.(*apiError); ok && (e.Code == "rate_not_found" || e.Code == "upstream_error" || e.Code == "unknown_store") {
+		code = e.Status
+	}
+	http.Error(w, err.Error(), code)
+}
+
+// envelope wraps every JSON response of the versioned API
+type envelope struct {
+	Data  interface{} `json:"data,omitempty"`
+	Error *apiError   `json:"error,omitempty"`
+}
+
+func writeData(w http.ResponseWriter, code int, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	err := json.NewEncoder(w).Encode(&envelope{Data: data})
+	if err != nil {
+		log.Println("writing response failed:", err)
+	}
+}
+
+// toAPIError returns err as an apiError, reporting the errors that are not as internal ones
+func toAPIError(err error) *apiError {
+	if e, ok := err.(*apiError); ok {
+		return e
+	}
+	return &apiError{Status: http.StatusInternalServerError, Code: "internal_error", Message: err.Error()}
+}
+
+func writeError(w http.ResponseWriter, err error) {
+	e := toAPIError(err)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(e.Status)
+	json.NewEncoder(w).Encode(&envelope{Error: e})
+}
+
+func notFoundHandler(w http.ResponseWriter, req *http.Request) {
+	writeError(w, &apiError{Status: http.StatusNotFound, Code: "route_not_found", Message: req.URL.Path + " does not exist"})
+}
+
+// addV1Routes registers the routes of the versioned API
+func (s *Server) addV1Routes(r *mux.Router) {
+	v1 := r.PathPrefix("/v1").Subrouter()
+	for _, rt := range s.v1Routes() {
+		v1.HandleFunc(rt.Path, rt.serve).Methods(rt.Method)
+	}
+	r.PathPrefix("/v1").HandlerFunc(notFoundHandler)
+}
+
+var (
+	storeParam      = apiParam{Name: "store", In: "path", Required: true, Description: "Name of the store to query"}
+	storeQueryParam = apiParam{Name: "store", Description: "Name of the store to query, the configured default store when omitted"}
+	fromParam       = apiParam{Name: "from", Required: true, Description: "Currency to convert from, e.g. USD"}
+	toParam         = apiParam{Name: "to", Required: true, Description: "Currency to convert to, e.g. EUR"}
+	dateParam       = apiParam{Name: "date", Description: "Date of the rate such as 2017-03-02, yesterday, -3d or end of last month, the last day of periods such as 2017-03, yesterday when omitted"}
+	startParam      = apiParam{Name: "start", Required: true, Description: "First date of the series such as 2017-03-02 or -1m, the first day of periods such as 2017-Q1"}
+	endParam        = apiParam{Name: "end", Description: "Last date of the series such as 2017-03-02 or last business day, the last day of periods such as 2017-Q1, at most 5 years after the start, yesterday when omitted"}
+	pivotsParam     = apiParam{Name: "pivots", Description: "Comma separated currencies preferred when deriving cross rates"}
+	idParam         = apiParam{Name: "id", In: "path", Required: true, Description: "ID of the alert rule"}
+)
+
+// v1Routes returns the routes of the versioned API. They are used both to route requests and to
+// generate the OpenAPI document
+func (s *Server) v1Routes() []apiRoute {
+	return []apiRoute{
+		{
+			Method: "GET", Path: "/stores", Summary: "List the names of the available stores",
+			Response: arrayOf(schema{"type": "string"}),
+			handler:  v1StoresHandler,
+		},
+		{
+			Method: "GET", Path: "/stores/{store}/rate", Summary: "Get the exchange rate of a currency pair on a date",
+			Params:   []apiParam{storeParam, fromParam, toParam, dateParam, pivotsParam},
+			Response: ref("Rate"),
+			handler:  cached(rateFixing, v1RateHandler),
+		},
+		{
+			Method: "GET", Path: "/stores/{store}/series", Summary: "Get the exchange rates of a currency pair over a date range",
+			Params: []apiParam{storeParam, fromParam, toParam, startParam, endParam,
+				{Name: "period", Enum: []string{"daily", "weekly", "monthly", "quarterly", "yearly"}, Description: "Period to resample the rates to, daily when omitted"},
+				{Name: "method", Enum: []string{"last", "end", "avg", "ohlc"}, Description: "How the rates of a period are combined, last when omitted"},
+				{Name: "fill", Enum: []string{"days", "weekdays"}, Description: "Fill the gaps in the series with the previous rate"},
+				{Name: "format", Enum: []string{"json", "csv", "ndjson"}, Description: "Output format, also negotiable with the Accept header"},
+			},
+			Response: schema{"oneOf": []schema{arrayOf(ref("DateRate")), arrayOf(ref("OHLC"))}},
+			Produces: []string{"text/csv", "application/x-ndjson"},
+			handler:  cached(seriesFixing, v1SeriesHandler),
+		},
+		{
+			Method: "GET", Path: "/matrix", Summary: "Get the cross rates between a set of currencies",
+			Params: []apiParam{storeQueryParam, dateParam,
+				{Name: "currencies", Required: true, Description: "Comma separated currencies of the matrix"},
+				{Name: "format", Enum: []string{"json", "csv"}, Description: "Output format, also negotiable with the Accept header"},
+			},
+			Response: ref("Matrix"),
+			Produces: []string{"text/csv"},
+			handler:  cached(matrixFixing, v1MatrixHandler),
+		},
+		{
+			Method: "POST", Path: "/batch", Summary: "Get many exchange rates in a single request",
+			Params: []apiParam{storeQueryParam,
+				{Name: "concurrency", Type: "integer", Description: "Number of queries answered concurrently, 4 when omitted"},
+			},
+			Body:     arrayOf(ref("BatchQuery")),
+			Response: arrayOf(ref("BatchItem")),
+			handler:  v1BatchHandler,
+		},
+		{
+			Method: "GET", Path: "/analytics", Summary: "Get statistics of the exchange rates of a currency pair over a date range",
+			Params: []apiParam{storeQueryParam, fromParam, toParam, startParam, endParam,
+				{Name: "window", Type: "integer", Description: "Number of returns in the rolling volatility window, 20 when omitted"},
+				{Name: "returns", Enum: []string{"log", "simple"}, Description: "Kind of returns computed, log when omitted"},
+				{Name: "compare", Description: "Currency pair to correlate with, e.g. EUR/GBP"},
+			},
+			Response: ref("Analytics"),
+			handler:  cached(analyticsFixing, v1AnalyticsHandler),
+		},
+		{
+			Method: "GET", Path: "/chart", Summary: "Get a chart of the exchange rates of a currency pair over a month",
+			Params: []apiParam{fromParam, toParam,
+				{Name: "month", Type: "integer", Required: true, Description: "Month of the chart, 1 to 12"},
+				{Name: "year", Type: "integer", Required: true, Description: "Year of the chart"},
+				{Name: "format", Enum: []string{"gif", "png", "svg"}, Description: "Image format, an animated GIF when omitted"},
+				{Name: "width", Type: "integer", Description: "Width of the chart in pixels, at most 2048, 1024 when omitted"},
+				{Name: "height", Type: "integer", Description: "Height of the chart in pixels, at most 2048, 400 when omitted"},
+				{Name: "dpi", Type: "number", Description: "Resolution the text is drawn at, at most 300, 92 when omitted"},
+				{Name: "theme", Enum: []string{"light", "dark"}, Description: "Colour scheme, light when omitted"},
+				{Name: "color", Description: "Hex colour of the line such as #1f77b4, the theme's when omitted"},
+				{Name: "title", Description: "Title drawn above the chart"},
+				{Name: "xlabel", Description: "Label of the date axis"},
+				{Name: "ylabel", Description: "Label of the rate axis, FROM to TO exchange rate when omitted"},
+				{Name: "date_format", Description: "Go time layout the dates are written with, such as Jan 2"},
+				{Name: "grid", Type: "boolean", Description: "Draw gridlines"},
+			},
+			Produces: []string{"image/gif", "image/png", "image/svg+xml"},
+			handler:  cached(chartFixing, v1ChartHandler),
+		},
+		{
+			Method: "GET", Path: "/chart/compare", Summary: "Get a chart comparing the exchange rates of several currency pairs over a date range",
+			Params: []apiParam{storeQueryParam,
+				{Name: "pairs", Required: true, Description: "Comma separated currency pairs charted, at most 8, e.g. EURUSD,EUR/GBP"},
+				{Name: "secondary", Description: "Comma separated pairs drawn against the left axis, for rates of another scale"},
+				startParam, endParam,
+				{Name: "rebase", Type: "boolean", Description: "Draw every pair as a percentage of its first rate, 100 at the start date"},
+				{Name: "format", Enum: []string{"png", "svg"}, Description: "Image format, PNG when omitted"},
+				{Name: "width", Type: "integer", Description: "Width of the chart in pixels, at most 2048, 1024 when omitted"},
+				{Name: "height", Type: "integer", Description: "Height of the chart in pixels, at most 2048, 400 when omitted"},
+				{Name: "dpi", Type: "number", Description: "Resolution the text is drawn at, at most 300, 92 when omitted"},
+				{Name: "theme", Enum: []string{"light", "dark"}, Description: "Colour scheme, light when omitted"},
+				{Name: "color", Description: "Hex colour of the first pair's line such as #1f77b4, the theme's when omitted"},
+				{Name: "title", Description: "Title drawn above the chart"},
+				{Name: "xlabel", Description: "Label of the date axis"},
+				{Name: "ylabel", Description: "Label of the rate axis, exchange rate or rebased to 100 when omitted"},
+				{Name: "date_format", Description: "Go time layout the dates are written with, such as Jan 2"},
+				{Name: "grid", Type: "boolean", Description: "Draw gridlines"},
+			},
+			Produces: []string{"image/png", "image/svg+xml"},
+			handler:  cached(compareFixing, v1CompareChartHandler),
+		},
+		{
+			Method: "GET", Path: "/alerts", Summary: "List the alert rules of the API key",
+			Response: arrayOf(ref("AlertRule")),
+			handler:  s.v1AlertsHandler,
+		},
+		{
+			Method: "POST", Path: "/alerts", Summary: "Create an alert rule",
+			Body:     ref("AlertRuleInput"),
+			Status:   http.StatusCreated,
+			Response: ref("AlertRule"),
+			handler:  s.v1AlertsHandler,
+		},
+		{
+			Method: "GET", Path: "/alerts/{id}", Summary: "Get an alert rule",
+			Params:   []apiParam{idParam},
+			Response: ref("AlertRule"),
+			handler:  s.v1AlertsHandler,
+		},
+		{
+			Method: "PUT", Path: "/alerts/{id}", Summary: "Replace an alert rule, keeping its secret when none is given",
+			Params:   []apiParam{idParam},
+			Body:     ref("AlertRuleInput"),
+			Response: ref("AlertRule"),
+			handler:  s.v1AlertsHandler,
+		},
+		{
+			Method: "DELETE", Path: "/alerts/{id}", Summary: "Delete an alert rule",
+			Params:  []apiParam{idParam},
+			Status:  http.StatusNoContent,
+			handler: s.v1AlertsHandler,
+		},
+		{
+			Method: "GET", Path: "/alerts/{id}/history", Summary: "List the firings of an alert rule",
+			Params:   []apiParam{idParam},
+			Response: arrayOf(ref("AlertFiring")),
+			handler:  s.v1AlertHistoryHandler,
+		},
+		{
+			Method: "GET", Path: "/usage", Summary: "Get the usage counters of the API key of the request",
+			Response: ref("Usage"),
+			handler:  s.v1UsageHandler,
+		},
+	}
+}
+
+func v1StoresHandler(w http.ResponseWriter, req *http.Request) {
+	writeData(w, http.StatusOK, StoreNames())
+}
+
+func v1RateHandler(w http.ResponseWriter, req *http.Request) {
+	res, err := getRate(mux.Vars(req)["store"], req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, http.StatusOK, res)
+}
+
+type dateRateJSON struct {
+	Date string  `json:"date"`
+	Rate float64 `json:"rate"`
+}
+
+type ohlcJSON struct {
+	Date  string  `json:"date"`
+	Open  float64 `json:"open"`
+	High  float64 `json:"high"`
+	Low   float64 `json:"low"`
+	Close float64 `json:"close"`
+}
+
+func toDateRatesJSON(rates []exchangerates.DateRate) []dateRateJSON {
+	result := make([]dateRateJSON, len(rates))
+	for i, r := range rates {
+		result[i] = dateRateJSON{Date: r.Date.Format("2006-01-02"), Rate: r.Rate}
+	}
+	return result
+}
+
+func toBarsJSON(bars []series.OHLC) []ohlcJSON {
+	result := make([]ohlcJSON, len(bars))
+	for i, b := range bars {
+		result[i] = ohlcJSON{Date: b.Date.Format("2006-01-02"), Open: b.Open, High: b.High, Low: b.Low, Close: b.Close}
+	}
+	return result
+}
+
+func v1SeriesHandler(w http.ResponseWriter, req *http.Request) {
+	rates, bars, err := getSeries(mux.Vars(req)["store"], req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	format := responseFormat(req)
+	switch {
+	case format != "json" && bars != nil:
+		err = writeBars(w, format, bars)
+	case format != "json":
+		err = writeRates(w, format, rates)
+	case bars != nil:
+		writeData(w, http.StatusOK, toBarsJSON(bars))
+	default:
+		writeData(w, http.StatusOK, toDateRatesJSON(rates))
+	}
+	if err != nil {
+		log.Println("writing response failed:", err)
+	}
+}
+
+func v1MatrixHandler(w http.ResponseWriter, req *http.Request) {
+	res, err := getMatrix(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	if responseFormat(req) == "csv" {
+		w.Header().Set("Content-Type", "text/csv")
+		err = writeMatrixCSV(w, res.Currencies, res.Rates)
+		if err != nil {
+			log.Println("writing response failed:", err)
+		}
+		return
+	}
+	writeData(w, http.StatusOK, res)
+}
+
+type batchItemJSON struct {
+	From  string    `json:"from"`
+	To    string    `json:"to"`
+	Date  string    `json:"date"`
+	Rate  float64   `json:"rate,omitempty"`
+	Error *apiError `json:"error,omitempty"`
+}
+
+func v1BatchHandler(w http.ResponseWriter, req *http.Request) {
+	results, err := runBatch(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+
+	items := make([]batchItemJSON, len(results))
+	for i, res := range results {
+		items[i] = batchItemJSON{From: res.From, To: res.To, Date: res.Date, Rate: res.Rate}
+		if res.Err != nil {
+			items[i].Error = toAPIError(res.Err)
+		}
+	}
+	writeData(w, http.StatusOK, items)
+}
+
+type drawdownJSON struct {
+	Peak   dateRateJSON `json:"peak"`
+	Trough dateRateJSON `json:"trough"`
+	Value  float64      `json:"value"`
+}
+
+type analyticsJSON struct {
+	Min                  dateRateJSON   `json:"min"`
+	Max                  dateRateJSON   `json:"max"`
+	MaxDrawdown          drawdownJSON   `json:"maxDrawdown"`
+	Volatility           float64        `json:"volatility"`
+	AnnualisedVolatility float64        `json:"annualisedVolatility"`
+	Returns              []dateRateJSON `json:"returns"`
+	RollingVolatility    []dateRateJSON `json:"rollingVolatility"`
+	ZScores              []dateRateJSON `json:"zScores"`
+	Correlation          *float64       `json:"correlation,omitempty"`
+}
+
+func toAnalyticsJSON(s *analytics.Summary, corr *float64) *analyticsJSON {
+	one := func(r exchangerates.DateRate) dateRateJSON {
+		return toDateRatesJSON([]exchangerates.DateRate{r})[0]
+	}
+	return &analyticsJSON{
+		Min: one(s.Min),
+		Max: one(s.Max),
+		MaxDrawdown: drawdownJSON{
+			Peak:   one(s.MaxDrawdown.Peak),
+			Trough: one(s.MaxDrawdown.Trough),
+			Value:  s.MaxDrawdown.Value,
+		},
+		Volatility:           s.Volatility,
+		AnnualisedVolatility: s.AnnualisedVolatility,
+		Returns:              toDateRatesJSON(s.Returns),
+		RollingVolatility:    toDateRatesJSON(s.RollingVolatility),
+		ZScores:              toDateRatesJSON(s.ZScores),
+		Correlation:          corr,
+	}
+}
+
+func v1AnalyticsHandler(w http.ResponseWriter, req *http.Request) {
+	summary, corr, err := getAnalytics(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	writeData(w, http.StatusOK, toAnalyticsJSON(summary, corr))
+}
+
+func v1ChartHandler(w http.ResponseWriter, req *http.Request) {
+	img, format, err := getChart(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	_, err = w.Write(img)
+	if err != nil {
+		log.Println("writing response failed:", err)
+	}
+}
+
+func v1CompareChartHandler(w http.ResponseWriter, req *http.Request) {
+	img, format, err := getCompareChart(req)
+	if err != nil {
+		writeError(w, err)
+		return
+	}
+	w.Header().Set("Content-Type", format.ContentType())
+	_, err = w.Write(img)
+	if err != nil {
+		log.Println("writing response failed:", err)
+	}
+}
+
+type ruleJSON struct {
+	ID        string    `json:"id"`
+	Store     string    `json:"store"`
+	From      string    `json:"from"`
+	To        string    `json:"to"`
+	Condition string    `json:"condition"`
+	Threshold float64   `json:"threshold"`
+	Webhook   string    `json:"webhook"`
+	Secret    string    `json:"secret,omitempty"`
+	LastFired string    `json:"lastFired,omitempty"`
+	Created   time.Time `json:"created"`
+}
+
+type firingJSON struct {
+	RuleID    string    `json:"ruleId"`
+	Date      string    `json:"date"`
+	Rate      float64   `json:"rate"`
+	Previous  float64   `json:"previous"`
+	Fired     time.Time `json:"fired"`
+	Delivered bool      `json:"delivered"`
+	Attempts  int       `json:"attempts"`
+	Error     string    `json:"error,omitempty"`
+}
+
+// toRuleJSON converts a rule for sending to clients, leaving out its secret
+func toRuleJSON(r alert.Rule) ruleJSON {
+	return ruleJSON{ID: r.ID, Store: r.Store, From: r.From, To: r.To, Condition: r.Condition,
+		Threshold: r.Threshold, Webhook: r.Webhook, LastFired: r.LastFired, Created: r.Created}
+}
+
+func (r ruleJSON) rule() alert.Rule {
+	return alert.Rule{Store: r.Store, From: r.From, To: r.To, Condition: r.Condition,
+		Threshold: r.Threshold, Webhook: r.Webhook, Secret: r.Secret}
+}
+
+func alertError(err error) error {
+	if err == alert.ErrNotFound {
+		return &apiError{Status: http.StatusNotFound, Code: "alert_not_found", Message: err.Error()}
+	}
+	return &apiError{Status: http.StatusBadRequest, Code: "invalid_alert", Message: err.Error()}
+}
+
+func (s *Server) v1AlertsHandler(w http.ResponseWriter, req *http.Request) {
+	if s.alerts == nil {
+		writeError(w, &apiError{Status: http.StatusNotFound, Code: "alerts_disabled", Message: "alerts are not enabled"})
+		return
+	}
+	owner, ok := s.alertOwner(req)
+	if !ok {
+		writeError(w, errAlertsAuth)
+		return
+	}
+
+	id := mux.Vars(req)["id"]
+	switch {
+	case req.Method == "GET" && id == "":
+		rules := s.alerts.List(owner)
+		result := make([]ruleJSON, len(rules))
+		for i, r := range rules {
+			result[i] = toRuleJSON(r)
+		}
+		writeData(w, http.StatusOK, result)
+	case req.Method == "GET":
+		r, err := s.alerts.Get(owner, id)
+		if err != nil {
+			writeError(w, alertError(err))
+			return
+		}
+		writeData(w, http.StatusOK, toRuleJSON(r))
+	case req.Method == "DELETE":
+		err := s.alerts.Delete(owner, id)
+		if err != nil {
+			writeError(w, alertError(err))
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		var in ruleJSON
+		err := json.NewDecoder(req.Body).Decode(&in)
+		if err != nil {
+			writeError(w, &apiError{Status: http.StatusBadRequest, Code: "invalid_body", Message: "invalid request body: " + err.Error()})
+			return
+		}
+
+		var r alert.Rule
+		code := http.StatusOK
+		if req.Method == "POST" {
+			r = in.rule()
+			r.Owner = owner
+			r, err = s.alerts.Create(r)
+			code = http.StatusCreated
+		} else {
+			r, err = s.alerts.Update(owner, id, in.rule())
+		}
+		if err != nil {
+			writeError(w, alertError(err))
+			return
+		}
+		writeData(w, code, toRuleJSON(r))
+	}
+}
+
+func (s *Server) v1AlertHistoryHandler(w http.ResponseWriter, req *http.Request) {
+	if s.alerts == nil {
+		writeError(w, &apiError{Status: http.StatusNotFound, Code: "alerts_disabled", Message: "alerts are not enabled"})
+		return
+	}
+	owner, ok := s.alertOwner(req)
+	if !ok {
+		writeError(w, errAlertsAuth)
+		return
+	}
+
+	history, err := s.alerts.History(owner, mux.Vars(req)["id"])
+	if err != nil {
+		writeError(w, alertError(err))
+		return
+	}
+	result := make([]firingJSON, len(history))
+	for i, f := range history {
+		result[i] = firingJSON{RuleID: f.RuleID, Date: f.Date, Rate: f.Rate, Previous: f.Previous,
+			Fired: f.Fired, Delivered: f.Delivered, Attempts: f.Attempts, Error: f.Error}
+	}
+	writeData(w, http.StatusOK, result)
+}
@@ -0,0 +1,148 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+func TestV1Envelopes(t *testing.T) {
+	var tests = []struct {
+		method       string
+		url          string
+		body         string
+		ExpectedCode int
+		ExpectedData string
+		ExpectedErr  *apiError
+	}{
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/rate?from=USD&to=EUR&date=2017-03-02",
+			ExpectedCode: http.StatusOK,
+			ExpectedData: `{"from":"USD","to":"EUR","date":"2017-03-02","store":"mock","rate":1}`,
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/rate?from=USD&to=EUR&date=20-03-02",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedErr:  &apiError{Code: "invalid_parameter", Message: "incorrect date format, should be similar to 2016-03-28"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/rate?from=USD&to=XYZ&date=2017-03-02",
+			ExpectedCode: http.StatusNotFound,
+			ExpectedErr:  &apiError{Code: "rate_not_found", Message: "currency XYZ not found"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/rate?from=USD&to=ERR&date=2017-03-02",
+			ExpectedCode: http.StatusBadGateway,
+			ExpectedErr:  &apiError{Code: "upstream_error", Message: "connection refused"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/nope/rate?from=USD&to=EUR&date=2017-03-02",
+			ExpectedCode: http.StatusNotFound,
+			ExpectedErr:  &apiError{Code: "unknown_store", Message: "nope is not a valid store"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/series?from=USD&to=EUR&start=2017-03-01&period=hourly",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedErr:  &apiError{Code: "invalid_parameter", Message: `"period" should be one of daily, weekly, monthly, quarterly or yearly`},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/series?from=USD&to=EUR&start=2017-03-20&end=2017-03-01",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedErr:  &apiError{Code: "invalid_parameter", Message: "the end date is before the start date"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/stores/mock/series?from=USD&to=EUR&start=2010-01-01&end=2017-03-01",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedErr:  &apiError{Code: "invalid_parameter", Message: "the start and end dates should be at most 5 years apart"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/analytics?store=mock&from=USD&to=EUR&start=2010-01-01&end=2017-03-01&compare=EUR/GBP",
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedErr:  &apiError{Code: "invalid_parameter", Message: "the start and end dates should be at most 5 years apart"},
+		},
+		{
+			method:       "POST",
+			url:          "/v1/batch?store=mock",
+			body:         `[{"from":"USD","to":"EUR","date":"2017-03-02"},{"from":"USD","to":"XYZ","date":"2017-03-02"},{"from":"USD","to":"ERR","date":"2017-03-02"}]`,
+			ExpectedCode: http.StatusOK,
+			ExpectedData: `[{"from":"USD","to":"EUR","date":"2017-03-02","rate":1},` +
+				`{"from":"USD","to":"XYZ","date":"2017-03-02","error":{"code":"rate_not_found","message":"currency XYZ not found"}},` +
+				`{"from":"USD","to":"ERR","date":"2017-03-02","error":{"code":"upstream_error","message":"connection refused"}}]`,
+		},
+		{
+			method:       "POST",
+			url:          "/v1/batch?store=mock",
+			body:         `{`,
+			ExpectedCode: http.StatusBadRequest,
+			ExpectedErr:  &apiError{Code: "invalid_body", Message: "invalid request body: unexpected EOF"},
+		},
+		{
+			method:       "GET",
+			url:          "/v1/nothing",
+			ExpectedCode: http.StatusNotFound,
+			ExpectedErr:  &apiError{Code: "route_not_found", Message: "/v1/nothing does not exist"},
+		},
+	}
+
+	s := New()
+
+	moc.OnGetExchangeRate = func(from, to string, date string) (float64, error) {
+		switch to {
+		case "XYZ":
+			return 0, exchangerates.NotFound("currency XYZ not found")
+		case "ERR":
+			return 0, errors.New("connection refused")
+		}
+		return 1.0, nil
+	}
+
+	for i, tt := range tests {
+		req, err := http.NewRequest(tt.method, tt.url, strings.NewReader(tt.body))
+		if err != nil {
+			t.Fatal(err)
+		}
+
+		rr := httptest.NewRecorder()
+		s.ServeHTTP(rr, req)
+
+		if rr.Code != tt.ExpectedCode {
+			t.Errorf("#%d failed: expected code=%v, got %v: %s", i, tt.ExpectedCode, rr.Code, rr.Body.String())
+			continue
+		}
+		if ct := rr.Header().Get("Content-Type"); ct != "application/json" {
+			t.Errorf("#%d failed: expected Content-Type=application/json, got %v", i, ct)
+		}
+
+		var resp struct {
+			Data  json.RawMessage
+			Error *apiError
+		}
+		err = json.NewDecoder(rr.Body).Decode(&resp)
+		if err != nil {
+			t.Errorf("#%d failed: %v", i, err)
+			continue
+		}
+
+		if string(resp.Data) != tt.ExpectedData {
+			t.Errorf("#%d failed: expected data=%s, got %s", i, tt.ExpectedData, resp.Data)
+		}
+		if !reflect.DeepEqual(resp.Error, tt.ExpectedErr) {
+			t.Errorf("#%d failed: expected error=%+v, got %+v", i, tt.ExpectedErr, resp.Error)
+		}
+	}
+}
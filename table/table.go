@@ -0,0 +1,179 @@
+// Package table implements the stores of central banks publishing the daily rates of several currencies
+// against their own as a single dataset, which is downloaded and held in memory
+package table
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/farhan-shahid/exchangerates"
+)
+
+// ParseFunc reads a dataset, returning the currencies it quotes and their values per unit of the base
+// currency keyed by date, such as 2017-03-02, and currency
+type ParseFunc func(r io.Reader) (currencies map[string]bool, rates map[string]map[string]float64, err error)
+
+// Store holds the dataset downloaded from a URL, quoting currencies against a base currency
+type Store struct {
+	sync.Mutex
+	base       string
+	url        string
+	parse      ParseFunc
+	currencies map[string]bool
+	rates      map[string]map[string]float64 //maps dates to currency values per unit of base
+	updated    time.Time                     //when the dataset was last loaded
+	err        error                         //error of the last refresh
+}
+
+// New returns a Store quoting currencies against base, whose dataset is downloaded from url and read with
+// parse. It holds no rates until refreshed or loaded
+func New(base, url string, parse ParseFunc) *Store {
+	return &Store{base: base, url: url, parse: parse}
+}
+
+// GetExchangeRate returns exchange rate from the dataset.
+// Use from and to for specifying the currencies to convert between
+// and date to specify the date of conversion
+func (s *Store) GetExchangeRate(from, to string, date string) (float64, error) {
+	fromVal, err := s.lookup(from, date)
+	if err != nil {
+		return 0, err
+	}
+
+	toVal, err := s.lookup(to, date)
+	if err != nil {
+		return 0, err
+	}
+
+	rate := exchangerates.CalcRate(fromVal, toVal)
+	return rate, nil
+}
+
+// GetMonthExchangeRates returns a list of exchange rate values for the month specified
+func (s *Store) GetMonthExchangeRates(from, to string, year, month int) ([]exchangerates.DateRate, error) {
+	rates := make([]exchangerates.DateRate, 0, 31)
+	for i := 1; i <= 31; i++ {
+		date := strconv.Itoa(year) + "-" + fmt.Sprintf("%02d", month) + "-" + fmt.Sprintf("%02d", i)
+
+		fromVal, err := s.lookup(from, date)
+		if err != nil {
+			continue
+		}
+		toVal, err := s.lookup(to, date)
+		if err != nil {
+			continue
+		}
+
+		rate := exchangerates.CalcRate(fromVal, toVal)
+		t, _ := time.Parse("2006-01-02", date)
+		rates = append(rates, exchangerates.DateRate{Rate: rate, Date: t})
+	}
+	if len(rates) == 0 {
+		return nil, exchangerates.NotFound("No data exists")
+	}
+	return rates, nil
+}
+
+// GetQuotes returns the direct quotes against the base currency held for the date specified
+func (s *Store) GetQuotes(date string) ([]exchangerates.Quote, error) {
+	s.Lock()
+	defer s.Unlock()
+
+	values, ok := s.rates[date]
+	if !ok {
+		return nil, exchangerates.NotFound("date not found")
+	}
+
+	quotes := make([]exchangerates.Quote, 0, len(values))
+	for curr, value := range values {
+		quotes = append(quotes, exchangerates.Quote{From: s.base, To: curr, Rate: value})
+	}
+	return quotes, nil
+}
+
+// Refresh downloads the dataset again, replacing the one currently held
+func (s *Store) Refresh() error {
+	err := s.fetchData()
+	s.Lock()
+	s.err = err
+	s.Unlock()
+	return err
+}
+
+// Updated returns the time the dataset was last loaded, zero if it never was
+func (s *Store) Updated() time.Time {
+	s.Lock()
+	defer s.Unlock()
+	return s.updated
+}
+
+// Status describes the dataset currently held
+func (s *Store) Status() exchangerates.Status {
+	s.Lock()
+	defer s.Unlock()
+	status := exchangerates.Status{Updated: s.updated, Rows: len(s.rates), Err: s.err}
+	for date := range s.rates {
+		if date > status.Latest {
+			status.Latest = date
+		}
+	}
+	return status
+}
+
+// Load replaces the dataset held with the one read from r, such as a file previously downloaded
+func (s *Store) Load(r io.Reader) error {
+	currencies, rates, err := s.parse(r)
+	if err != nil {
+		return err
+	}
+
+	s.Lock()
+	defer s.Unlock()
+	s.currencies = currencies
+	s.rates = rates
+	s.updated = time.Now()
+	return nil
+}
+
+func (s *Store) fetchData() error {
+	resp, err := http.Get(s.url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	// error pages are not datasets
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s responded with status %d", s.url, resp.StatusCode)
+	}
+	return s.Load(resp.Body)
+}
+
+func (s *Store) lookup(curr string, date string) (float64, error) {
+	if curr == s.base {
+		return 1, nil
+	}
+
+	s.Lock()
+	defer s.Unlock()
+
+	values, ok := s.rates[date]
+	if !ok {
+		return 0, exchangerates.NotFound("date not found")
+	}
+
+	if !s.currencies[curr] {
+		return 0, exchangerates.NotFound("currency " + curr + " not found")
+	}
+
+	value, ok := values[curr]
+	if !ok {
+		return 0, exchangerates.NotFound(curr + " data does not exist for " + date)
+	}
+
+	return value, nil
+}
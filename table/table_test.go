@@ -0,0 +1,81 @@
+package table
+
+import (
+	"bufio"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+// parseLines reads lines such as "2017-03-02 JPY 114.29"
+func parseLines(r io.Reader) (map[string]bool, map[string]map[string]float64, error) {
+	currencies := make(map[string]bool)
+	rates := make(map[string]map[string]float64)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		fields := strings.Fields(sc.Text())
+		value, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil {
+			return nil, nil, err
+		}
+		if rates[fields[0]] == nil {
+			rates[fields[0]] = make(map[string]float64)
+		}
+		rates[fields[0]][fields[1]] = value
+		currencies[fields[1]] = true
+	}
+	return currencies, rates, sc.Err()
+}
+
+func TestRefresh(t *testing.T) {
+	var tests = []struct {
+		Status        int
+		Body          string
+		ExpectedError bool
+		ExpectedRows  int
+	}{
+		{http.StatusOK, "2017-03-02 JPY 114.29\n2017-03-02 EUR 0.95\n2017-03-03 EUR 0.94\n", false, 2},
+		{http.StatusServiceUnavailable, "2017-03-02 JPY 114.29\n", true, 0},
+		{http.StatusNotFound, "<html>not found</html>", true, 0},
+	}
+
+	for i, tt := range tests {
+		ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(tt.Status)
+			io.WriteString(w, tt.Body)
+		}))
+		s := New("USD", ts.URL, parseLines)
+		err := s.Refresh()
+		ts.Close()
+
+		if (err != nil) != tt.ExpectedError {
+			t.Fatalf("#%d failed: expected error=%v, got %v", i, tt.ExpectedError, err)
+		}
+		if status := s.Status(); status.Rows != tt.ExpectedRows || status.Err != err {
+			t.Fatalf("#%d failed: unexpected status %+v", i, status)
+		}
+	}
+}
+
+func TestGetQuotes(t *testing.T) {
+	s := New("USD", "", parseLines)
+	if err := s.Load(strings.NewReader("2017-03-02 JPY 114.29\n2017-03-02 EUR 0.95\n")); err != nil {
+		t.Fatal(err)
+	}
+
+	quotes, err := s.GetQuotes("2017-03-02")
+	if err != nil || len(quotes) != 2 {
+		t.Fatalf("expected the 2 quotes of 2017-03-02, got %v %v", quotes, err)
+	}
+	for _, q := range quotes {
+		if q.From != "USD" {
+			t.Errorf("expected quotes against USD, got %+v", q)
+		}
+	}
+	if rate, err := s.GetExchangeRate("USD", "JPY", "2017-03-02"); err != nil || rate != 114.29 {
+		t.Errorf("expected the rate of JPY per USD, got %v %v", rate, err)
+	}
+}